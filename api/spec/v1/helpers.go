@@ -17,18 +17,98 @@
 package v1
 
 import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
 	"github.com/NVIDIA/vgpu-device-manager/pkg/types"
 )
 
+// ConfigNames returns the names of all 'VGPUConfigs' in a 'Spec' in sorted order.
+func (s *Spec) ConfigNames() []string {
+	names := make([]string, 0, len(s.VGPUConfigs))
+	for name := range s.VGPUConfigs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// GetConfig returns the 'VGPUConfigSpecSlice' with the given name and whether it exists in the 'Spec'.
+func (s *Spec) GetConfig(name string) (VGPUConfigSpecSlice, bool) {
+	config, exists := s.VGPUConfigs[name]
+	return config, exists
+}
+
+// DeviceFilterNone is a special device filter value that matches no devices.
+const DeviceFilterNone = "none"
+
+// ErrConfigConflict is returned when merging two 'Spec's that both define a 'VGPUConfig' with the same name.
+var ErrConfigConflict = errors.New("conflicting vgpu-config")
+
+// MergeOption is a function for passing options to 'Spec.Merge'.
+type MergeOption func(*mergeOptions)
+
+type mergeOptions struct {
+	overwriteOnConflict bool
+}
+
+// WithOverwriteOnConflict provides a 'MergeOption' that resolves a conflicting config name
+// by keeping the config from the 'Spec' being merged in, rather than returning 'ErrConfigConflict'.
+func WithOverwriteOnConflict(overwrite bool) MergeOption {
+	return func(o *mergeOptions) {
+		o.overwriteOnConflict = overwrite
+	}
+}
+
+// Merge returns a new 'Spec' containing the 'VGPUConfigs' of both 'Spec's.
+// If both define a config with the same name, an error wrapping 'ErrConfigConflict' is returned.
+func (s *Spec) Merge(other *Spec, opts ...MergeOption) (*Spec, error) {
+	o := mergeOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	for _, spec := range []*Spec{s, other} {
+		if spec.Version != "" && !IsSupported(spec.Version) {
+			return nil, fmt.Errorf("unknown version: %v", spec.Version)
+		}
+	}
+
+	merged := &Spec{
+		Version:     Version,
+		VGPUConfigs: make(map[string]VGPUConfigSpecSlice, len(s.VGPUConfigs)+len(other.VGPUConfigs)),
+	}
+	for name, config := range s.VGPUConfigs {
+		merged.VGPUConfigs[name] = config
+	}
+	for _, name := range other.ConfigNames() {
+		if _, exists := merged.VGPUConfigs[name]; exists && !o.overwriteOnConflict {
+			return nil, fmt.Errorf("%w: %v", ErrConfigConflict, name)
+		}
+		merged.VGPUConfigs[name] = other.VGPUConfigs[name]
+	}
+
+	return merged, nil
+}
+
 // MatchesDeviceFilter checks a 'VGPUConfigSpec' to see if its device filter matches the provided 'deviceID'.
+// An unset device filter matches all devices, while a filter of 'none' or an empty list matches no devices.
 func (vs *VGPUConfigSpec) MatchesDeviceFilter(deviceID types.DeviceID) bool {
 	var deviceFilter []string
 	switch df := vs.DeviceFilter.(type) {
 	case string:
+		if df == DeviceFilterNone {
+			return false
+		}
 		if df != "" {
 			deviceFilter = append(deviceFilter, df)
 		}
 	case []string:
+		if df != nil && len(df) == 0 {
+			return false
+		}
 		deviceFilter = df
 	}
 
@@ -37,6 +117,9 @@ func (vs *VGPUConfigSpec) MatchesDeviceFilter(deviceID types.DeviceID) bool {
 	}
 
 	for _, df := range deviceFilter {
+		if df == DeviceFilterNone {
+			return false
+		}
 		newDeviceID, _ := types.NewDeviceIDFromString(df)
 		if newDeviceID == deviceID {
 			return true
@@ -46,6 +129,102 @@ func (vs *VGPUConfigSpec) MatchesDeviceFilter(deviceID types.DeviceID) bool {
 	return false
 }
 
+// AssertValidDeviceFilter checks that every entry in the device filter of a 'VGPUConfigSpec' is a valid 'DeviceID'.
+// Entries that are not valid are accepted when parsing a 'VGPUConfigSpec', but never match any device.
+func (vs *VGPUConfigSpec) AssertValidDeviceFilter() error {
+	var deviceFilter []string
+	switch df := vs.DeviceFilter.(type) {
+	case string:
+		if df != "" {
+			deviceFilter = append(deviceFilter, df)
+		}
+	case []string:
+		deviceFilter = df
+	}
+
+	for _, df := range deviceFilter {
+		if df == DeviceFilterNone {
+			continue
+		}
+		_, err := types.NewDeviceIDFromString(df)
+		if err != nil {
+			return fmt.Errorf("invalid device filter: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// AssertValidDeviceFilters checks that the device filters of all 'VGPUConfigs' in a 'Spec' are valid.
+func (s *Spec) AssertValidDeviceFilters() error {
+	for _, name := range s.ConfigNames() {
+		for i := range s.VGPUConfigs[name] {
+			err := s.VGPUConfigs[name][i].AssertValidDeviceFilter()
+			if err != nil {
+				return fmt.Errorf("error validating '%v': %v", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// MatchesGPUModel checks a 'VGPUConfigSpec' to see if its model filter matches the provided 'modelName'.
+// A model filter entry matches if it appears anywhere in 'modelName', ignoring case (i.e. "A100" matches
+// "GA100 [A100 PCIe 40GB]"). An unset model filter matches all models, while an empty list matches none.
+func (vs *VGPUConfigSpec) MatchesGPUModel(modelName string) bool {
+	var modelFilter []string
+	switch mf := vs.ModelFilter.(type) {
+	case nil:
+		return true
+	case string:
+		modelFilter = append(modelFilter, mf)
+	case []string:
+		modelFilter = mf
+	}
+
+	modelName = strings.ToLower(modelName)
+	for _, mf := range modelFilter {
+		if mf != "" && strings.Contains(modelName, strings.ToLower(mf)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SortedByPriority returns a copy of a 'VGPUConfigSpecSlice' sorted by descending priority.
+// Entries with the same priority keep their relative order.
+func (s VGPUConfigSpecSlice) SortedByPriority() VGPUConfigSpecSlice {
+	sorted := make(VGPUConfigSpecSlice, len(s))
+	copy(sorted, s)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Priority > sorted[j].Priority
+	})
+	return sorted
+}
+
+// FilterByDeviceID returns a new 'VGPUConfigSpecSlice' holding only the entries whose device filter matches 'deviceID'.
+func (s VGPUConfigSpecSlice) FilterByDeviceID(deviceID types.DeviceID) VGPUConfigSpecSlice {
+	filtered := VGPUConfigSpecSlice{}
+	for i := range s {
+		if s[i].MatchesDeviceFilter(deviceID) {
+			filtered = append(filtered, s[i])
+		}
+	}
+	return filtered
+}
+
+// FilterByDeviceIndex returns a new 'VGPUConfigSpecSlice' holding only the entries whose 'devices' select the GPU at 'index'.
+func (s VGPUConfigSpecSlice) FilterByDeviceIndex(index int) VGPUConfigSpecSlice {
+	filtered := VGPUConfigSpecSlice{}
+	for i := range s {
+		if s[i].MatchesDevices(index) {
+			filtered = append(filtered, s[i])
+		}
+	}
+	return filtered
+}
+
 // MatchesAllDevices checks a 'VGPUConfigSpec' to see if it matches on 'all' devices.
 func (vs *VGPUConfigSpec) MatchesAllDevices() bool {
 	if devices, ok := vs.Devices.(string); ok {
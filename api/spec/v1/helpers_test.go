@@ -0,0 +1,350 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package v1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/yaml"
+
+	"github.com/NVIDIA/vgpu-device-manager/pkg/types"
+)
+
+func TestMatchesDeviceFilter(t *testing.T) {
+	a100 := types.NewDeviceID(0x20B0, 0x10DE)
+	t4 := types.NewDeviceID(0x1EB8, 0x10DE)
+
+	testCases := []struct {
+		description  string
+		deviceFilter string
+		matches      map[types.DeviceID]bool
+	}{
+		{
+			"No filter",
+			"",
+			map[types.DeviceID]bool{a100: true, t4: true},
+		},
+		{
+			"Single device",
+			`"device-filter": "0x20B010DE",`,
+			map[types.DeviceID]bool{a100: true, t4: false},
+		},
+		{
+			"List of devices",
+			`"device-filter": ["0x20B010DE", "0x1EB810DE"],`,
+			map[types.DeviceID]bool{a100: true, t4: true},
+		},
+		{
+			"None",
+			`"device-filter": "none",`,
+			map[types.DeviceID]bool{a100: false, t4: false},
+		},
+		{
+			"List with only none",
+			`"device-filter": ["none"],`,
+			map[types.DeviceID]bool{a100: false, t4: false},
+		},
+		{
+			"Empty list",
+			`"device-filter": [],`,
+			map[types.DeviceID]bool{a100: false, t4: false},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			s := VGPUConfigSpec{}
+			err := yaml.Unmarshal([]byte(`{`+tc.deviceFilter+`
+				"devices": "all",
+				"vgpu-devices": {
+					"A100-4C": 10
+				}
+			}`), &s)
+			require.Nil(t, err)
+			for deviceID, expected := range tc.matches {
+				require.Equal(t, expected, s.MatchesDeviceFilter(deviceID), "device %v", deviceID)
+			}
+		})
+	}
+}
+
+func TestMatchesGPUModel(t *testing.T) {
+	a100 := "GA100 [A100 PCIe 40GB]"
+	h100 := "GH100 [H100 PCIe]"
+
+	testCases := []struct {
+		description string
+		modelFilter string
+		matches     map[string]bool
+	}{
+		{
+			"No filter",
+			"",
+			map[string]bool{a100: true, h100: true},
+		},
+		{
+			"Single model",
+			`"model-filter": "A100",`,
+			map[string]bool{a100: true, h100: false},
+		},
+		{
+			"Single model ignoring case",
+			`"model-filter": "h100",`,
+			map[string]bool{a100: false, h100: true},
+		},
+		{
+			"List of models",
+			`"model-filter": ["A100", "H100"],`,
+			map[string]bool{a100: true, h100: true},
+		},
+		{
+			"Empty list",
+			`"model-filter": [],`,
+			map[string]bool{a100: false, h100: false},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			s := VGPUConfigSpec{}
+			err := yaml.Unmarshal([]byte(`{`+tc.modelFilter+`
+				"devices": "all",
+				"vgpu-devices": {
+					"A100-4C": 10
+				}
+			}`), &s)
+			require.Nil(t, err)
+			for model, expected := range tc.matches {
+				require.Equal(t, expected, s.MatchesGPUModel(model), "model %v", model)
+			}
+		})
+	}
+}
+
+func TestSortedByPriority(t *testing.T) {
+	testCases := []struct {
+		description string
+		priorities  []int
+		expected    []int
+	}{
+		{
+			"Empty slice",
+			[]int{},
+			[]int{},
+		},
+		{
+			"Default priorities keep their order",
+			[]int{0, 0, 0},
+			[]int{0, 1, 2},
+		},
+		{
+			"Descending priority",
+			[]int{0, 10, -1, 5},
+			[]int{1, 3, 0, 2},
+		},
+		{
+			"Equal priorities keep their relative order",
+			[]int{1, 2, 1, 2},
+			[]int{1, 3, 0, 2},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			// Each entry is identified by its original index in 'Devices'.
+			slice := VGPUConfigSpecSlice{}
+			for i, p := range tc.priorities {
+				slice = append(slice, VGPUConfigSpec{Devices: []int{i}, Priority: p})
+			}
+
+			sorted := slice.SortedByPriority()
+
+			order := []int{}
+			for _, vc := range sorted {
+				order = append(order, vc.Devices.([]int)[0])
+			}
+			require.Equal(t, tc.expected, order)
+			for i := range slice {
+				require.Equal(t, []int{i}, slice[i].Devices, "original slice must not be modified")
+			}
+		})
+	}
+}
+
+func TestFilterByDeviceID(t *testing.T) {
+	a100 := types.NewDeviceID(0x20B0, 0x10DE)
+	t4 := types.NewDeviceID(0x1EB8, 0x10DE)
+
+	all := VGPUConfigSpec{Devices: "all"}
+	onlyA100 := VGPUConfigSpec{Devices: []int{0}, DeviceFilter: "0x20B010DE"}
+	onlyT4 := VGPUConfigSpec{Devices: []int{1}, DeviceFilter: []string{"0x1EB810DE"}}
+	both := VGPUConfigSpec{Devices: []int{2}, DeviceFilter: []string{"0x20B010DE", "0x1EB810DE"}}
+	none := VGPUConfigSpec{Devices: []int{3}, DeviceFilter: DeviceFilterNone}
+
+	testCases := []struct {
+		description string
+		slice       VGPUConfigSpecSlice
+		deviceID    types.DeviceID
+		expected    VGPUConfigSpecSlice
+	}{
+		{
+			"Empty input",
+			VGPUConfigSpecSlice{},
+			a100,
+			VGPUConfigSpecSlice{},
+		},
+		{
+			"No filter matches every device",
+			VGPUConfigSpecSlice{all},
+			t4,
+			VGPUConfigSpecSlice{all},
+		},
+		{
+			"Specific device filter",
+			VGPUConfigSpecSlice{onlyA100, onlyT4},
+			a100,
+			VGPUConfigSpecSlice{onlyA100},
+		},
+		{
+			"Multi-filter entries",
+			VGPUConfigSpecSlice{all, onlyA100, onlyT4, both, none},
+			t4,
+			VGPUConfigSpecSlice{all, onlyT4, both},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			require.Equal(t, tc.expected, tc.slice.FilterByDeviceID(tc.deviceID))
+		})
+	}
+}
+
+func TestFilterByDeviceIndex(t *testing.T) {
+	all := VGPUConfigSpec{Devices: "all"}
+	first := VGPUConfigSpec{Devices: []int{0}}
+	firstTwo := VGPUConfigSpec{Devices: []int{0, 1}}
+	empty := VGPUConfigSpec{Devices: []int{}}
+
+	testCases := []struct {
+		description string
+		slice       VGPUConfigSpecSlice
+		index       int
+		expected    VGPUConfigSpecSlice
+	}{
+		{
+			"Empty input",
+			VGPUConfigSpecSlice{},
+			0,
+			VGPUConfigSpecSlice{},
+		},
+		{
+			"All devices",
+			VGPUConfigSpecSlice{all},
+			7,
+			VGPUConfigSpecSlice{all},
+		},
+		{
+			"Specific devices",
+			VGPUConfigSpecSlice{all, first, firstTwo, empty},
+			1,
+			VGPUConfigSpecSlice{all, firstTwo},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			require.Equal(t, tc.expected, tc.slice.FilterByDeviceIndex(tc.index))
+		})
+	}
+}
+
+func TestConfigNames(t *testing.T) {
+	s := Spec{
+		Version: Version,
+		VGPUConfigs: map[string]VGPUConfigSpecSlice{
+			"c": {},
+			"a": {},
+			"b": {},
+		},
+	}
+	require.Equal(t, []string{"a", "b", "c"}, s.ConfigNames())
+
+	_, exists := s.GetConfig("a")
+	require.True(t, exists)
+	_, exists = s.GetConfig("d")
+	require.False(t, exists)
+}
+
+func TestAssertValidDeviceFilter(t *testing.T) {
+	testCases := []struct {
+		description  string
+		deviceFilter interface{}
+		valid        bool
+	}{
+		{"No filter", nil, true},
+		{"Valid device ID", "0x20B010DE", true},
+		{"Valid list of device IDs", []string{"0x20B010DE", "0x1EB810DE"}, true},
+		{"None", DeviceFilterNone, true},
+		{"Model name", "MODEL", false},
+		{"List with model name", []string{"0x20B010DE", "MODEL"}, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			vs := VGPUConfigSpec{DeviceFilter: tc.deviceFilter}
+			err := vs.AssertValidDeviceFilter()
+			if tc.valid {
+				require.Nil(t, err)
+			} else {
+				require.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestMerge(t *testing.T) {
+	a := &Spec{
+		Version: Version,
+		VGPUConfigs: map[string]VGPUConfigSpecSlice{
+			"a":      {{Devices: "all", VGPUDevices: types.VGPUConfig{"A100-4C": 10}}},
+			"shared": {{Devices: "all", VGPUDevices: types.VGPUConfig{"A100-5C": 8}}},
+		},
+	}
+	b := &Spec{
+		Version: Version,
+		VGPUConfigs: map[string]VGPUConfigSpecSlice{
+			"b":      {{Devices: "all", VGPUDevices: types.VGPUConfig{"T4-1Q": 16}}},
+			"shared": {{Devices: "all", VGPUDevices: types.VGPUConfig{"T4-2Q": 8}}},
+		},
+	}
+
+	_, err := a.Merge(b)
+	require.ErrorIs(t, err, ErrConfigConflict)
+
+	merged, err := a.Merge(b, WithOverwriteOnConflict(true))
+	require.Nil(t, err)
+	require.Equal(t, []string{"a", "b", "shared"}, merged.ConfigNames())
+	require.Equal(t, b.VGPUConfigs["shared"], merged.VGPUConfigs["shared"])
+
+	delete(b.VGPUConfigs, "shared")
+	merged, err = a.Merge(b)
+	require.Nil(t, err)
+	require.Equal(t, []string{"a", "b", "shared"}, merged.ConfigNames())
+	require.Len(t, a.VGPUConfigs, 2)
+}
@@ -26,6 +26,11 @@ import (
 // Version indicates the version of the 'Spec' struct used to hold information on 'VGPUConfigs'.
 const Version = "v1"
 
+// IsSupported checks whether 'version' is a version of the 'Spec' struct that can be parsed by this package.
+func IsSupported(version string) bool {
+	return version == Version
+}
+
 // Spec is a versioned struct used to hold information on 'VGPUConfigs'.
 type Spec struct {
 	Version     string                         `json:"version" yaml:"version"`
@@ -35,8 +40,11 @@ type Spec struct {
 // VGPUConfigSpec defines the spec to declare the desired vGPU devices configuration for a set of GPUs.
 type VGPUConfigSpec struct {
 	DeviceFilter interface{}      `json:"device-filter,omitempty" yaml:"device-filter,flow,omitempty"`
+	ModelFilter  interface{}      `json:"model-filter,omitempty"  yaml:"model-filter,flow,omitempty"`
 	Devices      interface{}      `json:"devices"                 yaml:"devices,flow"`
 	VGPUDevices  types.VGPUConfig `json:"vgpu-devices"             yaml:"vgpu-devices"`
+	Priority     int              `json:"priority,omitempty"      yaml:"priority,omitempty"`
+	Comment      string           `json:"comment,omitempty"       yaml:"comment,omitempty"`
 }
 
 // VGPUConfigSpecSlice represents a slice of 'VGPUConfigSpec'.
@@ -63,7 +71,7 @@ func (s *Spec) UnmarshalJSON(b []byte) error {
 			if err != nil {
 				return err
 			}
-			if version != Version {
+			if !IsSupported(version) {
 				return fmt.Errorf("unknown version: %v", version)
 			}
 			result.Version = version
@@ -119,10 +127,33 @@ func (s *VGPUConfigSpec) UnmarshalJSON(b []byte) error {
 			var strslice []string
 			err2 := json.Unmarshal(v, &strslice)
 			if err2 == nil {
+				if len(strslice) > 1 && containsString(strslice, DeviceFilterNone) {
+					return fmt.Errorf("'%v' cannot be combined with other values in '%v'", DeviceFilterNone, k)
+				}
 				result.DeviceFilter = strslice
 				break
 			}
 			return fmt.Errorf("(%v, %v)", err1, err2)
+		case "model-filter":
+			var str string
+			err1 := json.Unmarshal(v, &str)
+			if err1 == nil {
+				if str == "" {
+					return fmt.Errorf("empty string input for '%v'", k)
+				}
+				result.ModelFilter = str
+				break
+			}
+			var strslice []string
+			err2 := json.Unmarshal(v, &strslice)
+			if err2 == nil {
+				if containsString(strslice, "") {
+					return fmt.Errorf("empty string input in '%v'", k)
+				}
+				result.ModelFilter = strslice
+				break
+			}
+			return fmt.Errorf("(%v, %v)", err1, err2)
 		case "devices":
 			var str string
 			err1 := json.Unmarshal(v, &str)
@@ -151,6 +182,20 @@ func (s *VGPUConfigSpec) UnmarshalJSON(b []byte) error {
 				return fmt.Errorf("error validating values in '%v' field: %v", k, err)
 			}
 			result.VGPUDevices = devices
+		case "priority":
+			var priority int
+			err := json.Unmarshal(v, &priority)
+			if err != nil {
+				return fmt.Errorf("invalid value for '%v': %v", k, err)
+			}
+			result.Priority = priority
+		case "comment":
+			var comment string
+			err := json.Unmarshal(v, &comment)
+			if err != nil {
+				return fmt.Errorf("invalid value for '%v': %v", k, err)
+			}
+			result.Comment = comment
 		default:
 			return fmt.Errorf("unexpected field: %v", k)
 		}
@@ -160,6 +205,15 @@ func (s *VGPUConfigSpec) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
+func containsString(s []string, str string) bool {
+	for _, v := range s {
+		if v == str {
+			return true
+		}
+	}
+	return false
+}
+
 func containsKey(m map[string]json.RawMessage, s string) bool {
 	_, exists := m[s]
 	return exists
@@ -21,6 +21,8 @@ import (
 
 	"github.com/stretchr/testify/require"
 	"sigs.k8s.io/yaml"
+
+	"github.com/NVIDIA/vgpu-device-manager/pkg/types"
 )
 
 func TestSpec(t *testing.T) {
@@ -177,6 +179,37 @@ func TestVGPUConfigSpec(t *testing.T) {
 			}`,
 			false,
 		},
+		{
+			"Well formed with 'max' count",
+			`{
+				"devices": "all",
+				"vgpu-devices": {
+					"A100-4C": "max"
+				}
+			}`,
+			false,
+		},
+		{
+			"'max' count combined with other vGPU types",
+			`{
+				"devices": "all",
+				"vgpu-devices": {
+					"A100-4C": "max",
+					"A100-5C": 4
+				}
+			}`,
+			true,
+		},
+		{
+			"Invalid string count",
+			`{
+				"devices": "all",
+				"vgpu-devices": {
+					"A100-4C": "all"
+				}
+			}`,
+			true,
+		},
 		{
 			"Well formed with filter",
 			`{
@@ -188,6 +221,116 @@ func TestVGPUConfigSpec(t *testing.T) {
 			}`,
 			false,
 		},
+		{
+			"Well formed with 'none' filter",
+			`{
+				"device-filter": "none",
+				"devices": "all",
+				"vgpu-devices": {
+					"A100-4C": 10
+				}
+			}`,
+			false,
+		},
+		{
+			"Well formed with empty filter list",
+			`{
+				"device-filter": [],
+				"devices": "all",
+				"vgpu-devices": {
+					"A100-4C": 10
+				}
+			}`,
+			false,
+		},
+		{
+			"Well formed with model filter",
+			`{
+				"model-filter": ["A100", "H100"],
+				"devices": "all",
+				"vgpu-devices": {
+					"A100-4C": 10
+				}
+			}`,
+			false,
+		},
+		{
+			"Empty model filter",
+			`{
+				"model-filter": "",
+				"devices": "all",
+				"vgpu-devices": {
+					"A100-4C": 10
+				}
+			}`,
+			true,
+		},
+		{
+			"Model filter of invalid type",
+			`{
+				"model-filter": 100,
+				"devices": "all",
+				"vgpu-devices": {
+					"A100-4C": 10
+				}
+			}`,
+			true,
+		},
+		{
+			"Filter list combining 'none' with other values",
+			`{
+				"device-filter": ["none", "0x20B010DE"],
+				"devices": "all",
+				"vgpu-devices": {
+					"A100-4C": 10
+				}
+			}`,
+			true,
+		},
+		{
+			"Well formed with priority",
+			`{
+				"devices": "all",
+				"priority": 10,
+				"vgpu-devices": {
+					"A100-4C": 10
+				}
+			}`,
+			false,
+		},
+		{
+			"Priority of invalid type",
+			`{
+				"devices": "all",
+				"priority": "high",
+				"vgpu-devices": {
+					"A100-4C": 10
+				}
+			}`,
+			true,
+		},
+		{
+			"Well formed with comment",
+			`{
+				"comment": "for H100 with MIG disabled",
+				"devices": "all",
+				"vgpu-devices": {
+					"A100-4C": 10
+				}
+			}`,
+			false,
+		},
+		{
+			"Comment of invalid type",
+			`{
+				"comment": ["not", "a", "string"],
+				"devices": "all",
+				"vgpu-devices": {
+					"A100-4C": 10
+				}
+			}`,
+			true,
+		},
 		{
 			"Erroneous field",
 			`{
@@ -230,3 +373,59 @@ func TestVGPUConfigSpec(t *testing.T) {
 	}
 
 }
+
+func TestVGPUConfigSpecMaxCountRoundTrip(t *testing.T) {
+	input := `
+devices: all
+vgpu-devices:
+  A100-4C: max
+`
+
+	var spec VGPUConfigSpec
+	err := yaml.Unmarshal([]byte(input), &spec)
+	require.Nil(t, err)
+	require.Equal(t, types.VGPUConfig{"A100-4C": types.VGPUCountMax}, spec.VGPUDevices)
+
+	output, err := yaml.Marshal(&spec)
+	require.Nil(t, err)
+	require.Contains(t, string(output), "A100-4C: max")
+
+	var roundTripped VGPUConfigSpec
+	err = yaml.Unmarshal(output, &roundTripped)
+	require.Nil(t, err)
+	require.Equal(t, spec, roundTripped)
+}
+
+func TestVGPUConfigSpecCommentRoundTrip(t *testing.T) {
+	input := `
+comment: for H100 with MIG disabled
+devices: all
+vgpu-devices:
+  H100-80C: 1
+`
+
+	var spec VGPUConfigSpec
+	err := yaml.Unmarshal([]byte(input), &spec)
+	require.Nil(t, err)
+	require.Equal(t, "for H100 with MIG disabled", spec.Comment)
+
+	output, err := yaml.Marshal(&spec)
+	require.Nil(t, err)
+	require.Contains(t, string(output), "comment: for H100 with MIG disabled")
+
+	var roundTripped VGPUConfigSpec
+	err = yaml.Unmarshal(output, &roundTripped)
+	require.Nil(t, err)
+	require.Equal(t, spec, roundTripped)
+
+	withoutComment := spec
+	withoutComment.Comment = ""
+	require.Equal(t, spec.MatchesDeviceFilter(0x20B010DE), withoutComment.MatchesDeviceFilter(0x20B010DE))
+	require.Equal(t, spec.MatchesDevices(0), withoutComment.MatchesDevices(0))
+}
+
+func TestIsSupported(t *testing.T) {
+	require.True(t, IsSupported(Version))
+	require.False(t, IsSupported(""))
+	require.False(t, IsSupported("v2"))
+}
@@ -0,0 +1,133 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	v1 "github.com/NVIDIA/vgpu-device-manager/api/spec/v1"
+	"github.com/NVIDIA/vgpu-device-manager/pkg/spec"
+)
+
+const configCacheExtension = ".json"
+
+// configCacheEntry is the on-disk representation of a parsed config file in <config-cache-dir>.
+// The modification time and size of the config file are recorded so that a stale entry can be detected.
+type configCacheEntry struct {
+	ModTime time.Time `json:"modTime"`
+	Size    int64     `json:"size"`
+	Spec    *v1.Spec  `json:"spec"`
+}
+
+// parseConfigFile parses the vGPU config file at 'path', detecting its format from the file
+// extension. If <config-cache-dir> is set, the
+// parsed 'Spec' is served from the cache as long as the file has not changed since it was cached.
+func parseConfigFile(path string) (*v1.Spec, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("stat error: %v", err)
+	}
+
+	var cachePath string
+	if configCacheDirFlag != "" {
+		cachePath = filepath.Join(configCacheDirFlag, filepath.Base(path)+configCacheExtension)
+		if s := readConfigCache(cachePath, fi); s != nil {
+			log.Debugf("Using cached config for '%s'", path)
+			return s, nil
+		}
+	}
+
+	configYaml, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read error: %v", err)
+	}
+
+	s, err := spec.Parse(configYaml, spec.DetectFormat(path))
+	if err != nil {
+		return nil, err
+	}
+
+	if cachePath != "" {
+		err = writeConfigCache(cachePath, fi, s)
+		if err != nil {
+			log.Warnf("Unable to cache config for '%s': %v", path, err)
+		}
+	}
+
+	return s, nil
+}
+
+// readConfigCache returns the cached 'Spec' at 'cachePath' if it is still valid for the config file
+// described by 'fi'. A missing, unreadable, or stale cache entry returns nil.
+func readConfigCache(cachePath string, fi os.FileInfo) *v1.Spec {
+	data, err := os.ReadFile(cachePath)
+	if err != nil {
+		return nil
+	}
+
+	var entry configCacheEntry
+	err = json.Unmarshal(data, &entry)
+	if err != nil {
+		log.Warnf("Ignoring invalid config cache entry '%s': %v", cachePath, err)
+		return nil
+	}
+
+	if !entry.ModTime.Equal(fi.ModTime()) || entry.Size != fi.Size() || entry.Spec == nil {
+		return nil
+	}
+
+	return entry.Spec
+}
+
+// writeConfigCache records 'spec' as the parsed contents of the config file described by 'fi'.
+// The entry is written to a temporary file first so a partially written entry is never read.
+func writeConfigCache(cachePath string, fi os.FileInfo, spec *v1.Spec) error {
+	entry := configCacheEntry{
+		ModTime: fi.ModTime(),
+		Size:    fi.Size(),
+		Spec:    spec,
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("marshal error: %v", err)
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(cachePath), filepath.Base(cachePath)+".*")
+	if err != nil {
+		return fmt.Errorf("unable to create temporary file: %v", err)
+	}
+	defer os.Remove(f.Name())
+
+	_, err = f.Write(data)
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("write error: %v", err)
+	}
+	err = f.Close()
+	if err != nil {
+		return fmt.Errorf("write error: %v", err)
+	}
+
+	return os.Rename(f.Name(), cachePath)
+}
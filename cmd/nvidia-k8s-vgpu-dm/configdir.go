@@ -0,0 +1,103 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+
+	v1 "github.com/NVIDIA/vgpu-device-manager/api/spec/v1"
+)
+
+const (
+	configDirExtension = ".yaml"
+	// configDirSeparator separates the file name from the config name in the
+	// keys of a merged config directory. Keys are used as label values, so
+	// '/' cannot be used here. File names must not contain it, so that every
+	// key can only be produced by a single file.
+	configDirSeparator = "."
+)
+
+// dirConfigSource is a 'ConfigSource' merging all config files in a directory.
+// The directory is re-read on every call so that configs can be added or removed at runtime.
+type dirConfigSource struct {
+	dir string
+}
+
+// GetConfigFile merges the config directory into a temporary config file.
+func (s *dirConfigSource) GetConfigFile() (string, func(), error) {
+	spec, err := loadConfigDir(s.dir)
+	if err != nil {
+		return "", nil, fmt.Errorf("unable to load config directory '%s': %v", s.dir, err)
+	}
+
+	output, err := yaml.Marshal(spec)
+	if err != nil {
+		return "", nil, fmt.Errorf("unable to marshal merged config: %v", err)
+	}
+
+	return writeTempConfigFile(output)
+}
+
+// loadConfigDir merges the vGPU configs from all '*.yaml' files in 'dir' into a single 'Spec'.
+// Each config is keyed by '<file>.<config>', where '<file>' is the file name without its extension.
+// File names that contain a '.' besides the extension are rejected, as their keys would be ambiguous.
+func loadConfigDir(dir string) (*v1.Spec, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("read error: %v", err)
+	}
+
+	merged := &v1.Spec{
+		Version:     v1.Version,
+		VGPUConfigs: make(map[string]v1.VGPUConfigSpecSlice),
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != configDirExtension {
+			continue
+		}
+
+		prefix := strings.TrimSuffix(entry.Name(), configDirExtension)
+		if strings.Contains(prefix, configDirSeparator) {
+			return nil, fmt.Errorf("invalid file name '%s': must not contain '%s' before the extension", entry.Name(), configDirSeparator)
+		}
+
+		spec, err := parseConfigFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse '%s': %v", entry.Name(), err)
+		}
+
+		prefixed := &v1.Spec{
+			Version:     spec.Version,
+			VGPUConfigs: make(map[string]v1.VGPUConfigSpecSlice),
+		}
+		for name, config := range spec.VGPUConfigs {
+			prefixed.VGPUConfigs[prefix+configDirSeparator+name] = config
+		}
+
+		merged, err = merged.Merge(prefixed, v1.WithOverwriteOnConflict(overwriteOnConflictFlag))
+		if err != nil {
+			return nil, fmt.Errorf("unable to merge '%s': %w", entry.Name(), err)
+		}
+	}
+
+	return merged, nil
+}
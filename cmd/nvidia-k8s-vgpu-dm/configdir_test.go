@@ -0,0 +1,189 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/stretchr/testify/require"
+
+	v1 "github.com/NVIDIA/vgpu-device-manager/api/spec/v1"
+)
+
+const testConfigDirFile = `version: v1
+vgpu-configs:
+  default:
+    - devices: all
+      vgpu-devices:
+        %s: 4
+`
+
+func writeTestConfigDirFile(t *testing.T, dir, name, vgpuType string) {
+	err := os.WriteFile(filepath.Join(dir, name), []byte(fmt.Sprintf(testConfigDirFile, vgpuType)), 0644)
+	require.NoError(t, err)
+}
+
+func TestLoadConfigDir(t *testing.T) {
+	testCases := []struct {
+		description  string
+		files        []string
+		expectedKeys []string
+		expectError  bool
+	}{
+		{
+			"Configs are keyed by file name",
+			[]string{"a100.yaml", "a30.yaml"},
+			[]string{"a100.default", "a30.default"},
+			false,
+		},
+		{
+			"Non-YAML files are ignored",
+			[]string{"a100.yaml", "README.md"},
+			[]string{"a100.default"},
+			false,
+		},
+		{
+			"File name containing the separator is rejected",
+			[]string{"a100.yaml", "a100.default.yaml"},
+			nil,
+			true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, file := range tc.files {
+				writeTestConfigDirFile(t, dir, file, "A100-4C")
+			}
+
+			spec, err := loadConfigDir(dir)
+			if tc.expectError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			var keys []string
+			for key := range spec.VGPUConfigs {
+				keys = append(keys, key)
+			}
+			require.ElementsMatch(t, tc.expectedKeys, keys)
+		})
+	}
+}
+
+func TestIsConfigDirEvent(t *testing.T) {
+	testCases := []struct {
+		description string
+		event       fsnotify.Event
+		expected    bool
+	}{
+		{
+			"Config file written",
+			fsnotify.Event{Name: "/configs/a100.yaml", Op: fsnotify.Write},
+			true,
+		},
+		{
+			"Config file removed",
+			fsnotify.Event{Name: "/configs/a100.yaml", Op: fsnotify.Remove},
+			true,
+		},
+		{
+			"ConfigMap data swapped",
+			fsnotify.Event{Name: "/configs/..data", Op: fsnotify.Create},
+			true,
+		},
+		{
+			"Other file written",
+			fsnotify.Event{Name: "/configs/README.md", Op: fsnotify.Write},
+			false,
+		},
+		{
+			"Config file chmod",
+			fsnotify.Event{Name: "/configs/a100.yaml", Op: fsnotify.Chmod},
+			false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			require.Equal(t, tc.expected, isConfigDirEvent(tc.event))
+		})
+	}
+}
+
+func TestResendOnSelectedConfigDirChange(t *testing.T) {
+	testCases := []struct {
+		description    string
+		changedFile    string
+		expectedResend bool
+	}{
+		{
+			"Selected config changed",
+			"a100.yaml",
+			true,
+		},
+		{
+			"Other config changed",
+			"a30.yaml",
+			false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			dir := t.TempDir()
+			writeTestConfigDirFile(t, dir, "a100.yaml", "A100-4C")
+			writeTestConfigDirFile(t, dir, "a30.yaml", "A30-4C")
+
+			config := &watchedConfig{
+				name: dir,
+				dir:  dir,
+				load: func() (*v1.Spec, error) {
+					return loadConfigDir(dir)
+				},
+			}
+			previous, err := config.load()
+			require.NoError(t, err)
+
+			vGPUConfig := NewSyncableVGPUConfig()
+			vGPUConfig.Set("a100.default")
+			require.Equal(t, "a100.default", vGPUConfig.GetCurrent())
+
+			writeTestConfigDirFile(t, dir, tc.changedFile, "A100-8C")
+			current := resendOnSelectedConfigChange(config, previous, vGPUConfig)
+			require.Contains(t, current.VGPUConfigs, "a100.default")
+
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+			value, err := vGPUConfig.GetWithContext(ctx)
+			if tc.expectedResend {
+				require.NoError(t, err)
+				require.Equal(t, "a100.default", value)
+			} else {
+				require.ErrorIs(t, err, context.DeadlineExceeded)
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+// configMapConfigKey is the key in the data of a <config-configmap> holding the vGPU config file.
+const configMapConfigKey = "config.yaml"
+
+// ConfigSource provides the vGPU configuration file to pass to nvidia-vgpu-dm.
+type ConfigSource interface {
+	// GetConfigFile returns the path of the config file, along with a function
+	// to clean it up once it is no longer needed.
+	GetConfigFile() (string, func(), error)
+}
+
+// newConfigSource returns the 'ConfigSource' selected by the <config-file>,
+// <config-dir>, and <config-configmap> flags.
+func newConfigSource(clientset kubernetes.Interface) ConfigSource {
+	switch {
+	case configDirFlag != "":
+		return &dirConfigSource{dir: configDirFlag}
+	case configConfigMapFlag != "":
+		namespace, name, _ := parseConfigMapRef(configConfigMapFlag)
+		return &configMapConfigSource{clientset: clientset, namespace: namespace, name: name}
+	default:
+		return &fileConfigSource{path: configFileFlag}
+	}
+}
+
+// fileConfigSource is a 'ConfigSource' for a config file on disk.
+type fileConfigSource struct {
+	path string
+}
+
+// GetConfigFile returns the path of the config file as is.
+func (s *fileConfigSource) GetConfigFile() (string, func(), error) {
+	return s.path, func() {}, nil
+}
+
+// configMapConfigSource is a 'ConfigSource' reading the config file from the
+// 'config.yaml' key of a ConfigMap. The ConfigMap is read on every call.
+type configMapConfigSource struct {
+	clientset kubernetes.Interface
+	namespace string
+	name      string
+}
+
+// GetConfigFile writes the config file from the ConfigMap to a temporary file.
+func (s *configMapConfigSource) GetConfigFile() (string, func(), error) {
+	cm, err := s.clientset.CoreV1().ConfigMaps(s.namespace).Get(context.TODO(), s.name, metav1.GetOptions{})
+	if err != nil {
+		return "", nil, fmt.Errorf("unable to get ConfigMap '%s/%s': %v", s.namespace, s.name, err)
+	}
+
+	config, ok := cm.Data[configMapConfigKey]
+	if !ok {
+		return "", nil, fmt.Errorf("ConfigMap '%s/%s' has no '%s' key", s.namespace, s.name, configMapConfigKey)
+	}
+
+	return writeTempConfigFile([]byte(config))
+}
+
+// parseConfigMapRef splits a '<namespace>/<name>' reference to a ConfigMap.
+func parseConfigMapRef(ref string) (string, string, error) {
+	parts := strings.Split(ref, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("'%s' is not of the form <namespace>/<name>", ref)
+	}
+	return parts[0], parts[1], nil
+}
+
+// writeTempConfigFile writes 'config' to a temporary file in <temp-dir> and returns its path,
+// along with a function to remove it that honours <cleanup-temp-files>.
+func writeTempConfigFile(config []byte) (string, func(), error) {
+	f, err := os.CreateTemp(tempDirFlag, "vgpu-config-*.yaml")
+	if err != nil {
+		return "", nil, fmt.Errorf("unable to create temporary config file: %v", err)
+	}
+	defer f.Close()
+
+	cleanup := func() {
+		if cleanupTempFilesFlag {
+			_ = os.Remove(f.Name())
+		}
+	}
+
+	_, err = f.Write(config)
+	if err != nil {
+		_ = os.Remove(f.Name())
+		return "", nil, fmt.Errorf("unable to write temporary config file: %v", err)
+	}
+
+	return f.Name(), cleanup, nil
+}
+
+// continuouslySyncConfigMapChanges watches the <config-configmap> and, whenever its config
+// file changes, re-sends the current vGPU config so that it is re-asserted against the new file.
+func continuouslySyncConfigMapChanges(clientset kubernetes.Interface, namespace, name string, vGPUConfig *SyncableVGPUConfig) (chan struct{}, error) {
+	listWatch := cache.NewListWatchFromClient(
+		clientset.CoreV1().RESTClient(),
+		"configmaps",
+		namespace,
+		fields.OneTermEqualSelector("metadata.name", name),
+	)
+
+	opts := cache.InformerOptions{
+		ListerWatcher: listWatch,
+		ObjectType:    &corev1.ConfigMap{},
+		Handler: cache.ResourceEventHandlerFuncs{
+			UpdateFunc: func(oldObj, newObj interface{}) {
+				oldConfig := oldObj.(*corev1.ConfigMap).Data[configMapConfigKey]
+				newConfig := newObj.(*corev1.ConfigMap).Data[configMapConfigKey]
+				if oldConfig == newConfig {
+					return
+				}
+				log.Infof("vGPU config file in ConfigMap '%s/%s' changed", namespace, name)
+				vGPUConfig.Resend(defaultVGPUConfigFlag)
+			},
+			DeleteFunc: func(obj interface{}) {
+				log.Warnf("ConfigMap '%s/%s' holding the vGPU config file was deleted", namespace, name)
+			},
+		},
+		ResyncPeriod: 0,
+	}
+	_, controller := cache.NewInformerWithOptions(opts)
+	stop := make(chan struct{})
+	go controller.Run(stop)
+	if !cache.WaitForCacheSync(stop, controller.HasSynced) {
+		close(stop)
+		return nil, fmt.Errorf("failed to wait for ConfigMap informer to sync")
+	}
+	return stop, nil
+}
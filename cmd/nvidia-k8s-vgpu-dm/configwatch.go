@@ -0,0 +1,178 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	log "github.com/sirupsen/logrus"
+
+	v1 "github.com/NVIDIA/vgpu-device-manager/api/spec/v1"
+)
+
+// configFileDebouncePeriod is how long the config file must go without changes before it is re-parsed.
+const configFileDebouncePeriod = 2 * time.Second
+
+// watchedConfig describes a source of vGPU configs on disk that is watched for changes.
+type watchedConfig struct {
+	// name is the path to the config file or directory, used in log messages.
+	name string
+	// dir is the directory watched for changes.
+	dir string
+	// isEvent checks if an event in 'dir' may have changed the configs.
+	isEvent func(fsnotify.Event) bool
+	// load reads the configs into a single 'Spec'.
+	load func() (*v1.Spec, error)
+}
+
+// continuouslySyncConfigFileChanges watches the <config-file> and, whenever the vGPU config
+// currently selected for the node changes in it, re-sends the current vGPU config so that it is
+// re-asserted against the new file.
+//
+// The parent directory is watched rather than the file itself, since editors and ConfigMap volume
+// mounts replace the file (or a '..data' symlink next to it) instead of writing to it in place.
+func continuouslySyncConfigFileChanges(path string, vGPUConfig *SyncableVGPUConfig) (chan struct{}, error) {
+	path = filepath.Clean(path)
+	return continuouslySyncConfigChanges(&watchedConfig{
+		name: path,
+		dir:  filepath.Dir(path),
+		isEvent: func(event fsnotify.Event) bool {
+			return isConfigFileEvent(path, event)
+		},
+		load: func() (*v1.Spec, error) {
+			return parseConfigFile(path)
+		},
+	}, vGPUConfig)
+}
+
+// continuouslySyncConfigDirChanges watches the <config-dir> and, whenever the vGPU config currently
+// selected for the node changes in the merged configs, e.g. because a config file in it was created,
+// changed or deleted, re-sends the current vGPU config so that it is re-asserted against them.
+func continuouslySyncConfigDirChanges(dir string, vGPUConfig *SyncableVGPUConfig) (chan struct{}, error) {
+	dir = filepath.Clean(dir)
+	return continuouslySyncConfigChanges(&watchedConfig{
+		name:    dir,
+		dir:     dir,
+		isEvent: isConfigDirEvent,
+		load: func() (*v1.Spec, error) {
+			return loadConfigDir(dir)
+		},
+	}, vGPUConfig)
+}
+
+// continuouslySyncConfigChanges watches the directory of 'config' and re-sends the current vGPU
+// config whenever the selected config changes in it, once no further events arrive for
+// 'configFileDebouncePeriod'.
+func continuouslySyncConfigChanges(config *watchedConfig, vGPUConfig *SyncableVGPUConfig) (chan struct{}, error) {
+	current, err := config.load()
+	if err != nil {
+		log.Warnf("Unable to load vGPU configs from '%s': %v", config.name, err)
+		current = &v1.Spec{}
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("unable to create config file watcher: %v", err)
+	}
+
+	err = watcher.Add(config.dir)
+	if err != nil {
+		watcher.Close()
+		return nil, fmt.Errorf("unable to watch '%s': %v", config.dir, err)
+	}
+
+	stop := make(chan struct{})
+	go func() {
+		defer watcher.Close()
+		var debounce <-chan time.Time
+		for {
+			select {
+			case <-stop:
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if !config.isEvent(event) {
+					continue
+				}
+				log.Debugf("Config file event: %v", event)
+				debounce = time.After(configFileDebouncePeriod)
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				log.Warnf("Error watching '%s': %v", config.name, err)
+			case <-debounce:
+				debounce = nil
+				current = resendOnSelectedConfigChange(config, current, vGPUConfig)
+			}
+		}
+	}()
+
+	return stop, nil
+}
+
+// isConfigFileEvent checks if 'event' is a write to, or the creation of, the config file at 'path'
+// (or of the hidden entries a ConfigMap volume mount uses to atomically swap it).
+func isConfigFileEvent(path string, event fsnotify.Event) bool {
+	if !event.Has(fsnotify.Write) && !event.Has(fsnotify.Create) {
+		return false
+	}
+	name := filepath.Clean(event.Name)
+	return name == path || strings.HasPrefix(filepath.Base(name), "..")
+}
+
+// isConfigDirEvent checks if 'event' is the creation, change or deletion of a config file in the
+// config directory (or of the hidden entries a ConfigMap volume mount uses to atomically swap them).
+func isConfigDirEvent(event fsnotify.Event) bool {
+	if !event.Has(fsnotify.Write) && !event.Has(fsnotify.Create) && !event.Has(fsnotify.Remove) && !event.Has(fsnotify.Rename) {
+		return false
+	}
+	name := filepath.Base(event.Name)
+	return filepath.Ext(name) == configDirExtension || strings.HasPrefix(name, "..")
+}
+
+// resendOnSelectedConfigChange reloads the vGPU configs of 'config' and re-sends the current vGPU
+// config if its 'VGPUConfigSpec' entries differ from those in 'previous'. It returns the 'Spec'
+// to compare against on the next change.
+func resendOnSelectedConfigChange(config *watchedConfig, previous *v1.Spec, vGPUConfig *SyncableVGPUConfig) *v1.Spec {
+	s, err := config.load()
+	if err != nil {
+		log.Warnf("Unable to load changed vGPU configs from '%s', ignoring change: %v", config.name, err)
+		return previous
+	}
+
+	selectedConfig := vGPUConfig.Peek()
+	if selectedConfig == "" {
+		selectedConfig = defaultVGPUConfigFlag
+	}
+
+	if reflect.DeepEqual(previous.VGPUConfigs[selectedConfig], s.VGPUConfigs[selectedConfig]) {
+		log.Debugf("'%s' changed, but not for the selected vGPU config '%s'", config.name, selectedConfig)
+		return s
+	}
+
+	log.Infof("vGPU config '%s' changed in '%s'", selectedConfig, config.name)
+	vGPUConfig.Resend(defaultVGPUConfigFlag)
+	return s
+}
@@ -0,0 +1,70 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+const (
+	eventSourceComponent = "nvidia-k8s-vgpu-dm"
+
+	eventReasonVGPUConfigApplied = "VGPUConfigApplied"
+	eventReasonVGPUConfigFailed  = "VGPUConfigFailed"
+	eventReasonVGPUConfigPending = "VGPUConfigPending"
+)
+
+// eventRecorder records Events about vGPU config transitions on the node.
+// It is nil until newEventRecorder is called, in which case no Events are recorded.
+var eventRecorder record.EventRecorder
+
+// newEventRecorder creates an 'EventRecorder' that posts Events through 'clientset'.
+// The returned function stops the underlying broadcaster.
+func newEventRecorder(clientset kubernetes.Interface) (record.EventRecorder, func()) {
+	broadcaster := record.NewBroadcaster()
+	broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: clientset.CoreV1().Events("")})
+	recorder := broadcaster.NewRecorder(runtime.NewScheme(), corev1.EventSource{Component: eventSourceComponent, Host: nodeNameFlag})
+	return recorder, broadcaster.Shutdown
+}
+
+// recordVGPUConfigStateEvent records an Event on the node for a transition of the vGPU config state to 'value'.
+func recordVGPUConfigStateEvent(selectedConfig, value string, applyErr error) {
+	if eventRecorder == nil {
+		return
+	}
+
+	// Nodes are cluster-scoped and, by convention, referenced by Events with their name as UID.
+	node := &corev1.ObjectReference{
+		Kind: "Node",
+		Name: nodeNameFlag,
+		UID:  types.UID(nodeNameFlag),
+	}
+
+	switch value {
+	case "success":
+		eventRecorder.Eventf(node, corev1.EventTypeNormal, eventReasonVGPUConfigApplied, "Applied vGPU config '%s'", selectedConfig)
+	case "failed":
+		eventRecorder.Eventf(node, corev1.EventTypeWarning, eventReasonVGPUConfigFailed, "Failed to apply vGPU config '%s': %v", selectedConfig, applyErr)
+	case "pending":
+		eventRecorder.Eventf(node, corev1.EventTypeNormal, eventReasonVGPUConfigPending, "Applying vGPU config '%s'", selectedConfig)
+	}
+}
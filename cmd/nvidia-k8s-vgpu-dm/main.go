@@ -17,48 +17,93 @@
 package main
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
 	"os"
 	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"strings"
 
 	log "github.com/sirupsen/logrus"
 	cli "github.com/urfave/cli/v2"
+	"golang.org/x/sync/errgroup"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/util/retry"
 
 	"context"
 	"sync"
+	"syscall"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/fields"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/wait"
 
 	"github.com/NVIDIA/vgpu-device-manager/internal/info"
+	"github.com/NVIDIA/vgpu-device-manager/internal/metrics"
 )
 
 const (
-	cliName              = "nvidia-vgpu-dm"
-	resourceNodes        = "nodes"
-	vGPUConfigLabel      = "nvidia.com/vgpu.config"
-	vGPUConfigStateLabel = "nvidia.com/vgpu.config.state"
-	pluginStateLabel     = "nvidia.com/gpu.deploy.sandbox-device-plugin"
-	validatorStateLabel  = "nvidia.com/gpu.deploy.sandbox-validator"
+	cliName             = "nvidia-vgpu-dm"
+	resourceNodes       = "nodes"
+	defaultLabelPrefix  = "nvidia.com/vgpu"
+	pluginStateLabel    = "nvidia.com/gpu.deploy.sandbox-device-plugin"
+	validatorStateLabel = "nvidia.com/gpu.deploy.sandbox-validator"
 )
 
+// The names of the node labels and annotations managed by the vGPU Device
+// Manager. They are derived from <label-prefix> by setLabelNames().
 var (
-	kubeconfigFlag        string
-	nodeNameFlag          string
-	namespaceFlag         string
-	configFileFlag        string
-	defaultVGPUConfigFlag string
+	vGPUConfigLabel       = defaultLabelPrefix + ".config"
+	vGPUConfigStateLabel  = defaultLabelPrefix + ".config.state"
+	lastAppliedAnnotation = defaultLabelPrefix + ".config.last-applied"
+)
+
+// dryRunChangesPendingExitCode is the exit code of 'nvidia-vgpu-dm apply --dry-run' when changes would be made
+const dryRunChangesPendingExitCode = 2
+
+var (
+	kubeconfigFlag          string
+	nodeNameFlag            string
+	namespaceFlag           string
+	extraNamespacesFlag     cli.StringSlice
+	configFileFlag          string
+	configDirFlag           string
+	configConfigMapFlag     string
+	overwriteOnConflictFlag bool
+	defaultVGPUConfigFlag   string
+	pendingTimeoutFlag      time.Duration
+	forceApplyAfterFlag     time.Duration
+	vgpuConfigTimeoutFlag   time.Duration
+	stateConfigMapFlag      string
+	labelPrefixFlag         string
+	useAnnotationFlag       bool
+	tempDirFlag             string
+	configCacheDirFlag      string
+	dryRunFlag              bool
+	metricsAddrFlag         string
+	statusFileFlag          string
+	cleanupTempFilesFlag    bool
+
+	startTime time.Time
+)
 
+// operandState holds the values of the GPU operand state labels on the node,
+// as observed before they are paused for a reconfiguration.
+type operandState struct {
 	pluginDeployed    string
 	validatorDeployed string
-)
+}
 
 // SyncableVGPUConfig is used to synchronize on changes to a configuration value.
 // That is, callers of Get() will block until a call to Set() is made.
@@ -92,11 +137,59 @@ func (m *SyncableVGPUConfig) Set(value string) {
 // Get gets the value of the config.
 // A call to Get() will block until a subsequent Set() call is made.
 func (m *SyncableVGPUConfig) Get() string {
+	value, _ := m.GetWithContext(context.Background())
+	return value
+}
+
+// GetWithContext gets the value of the config like Get(), but stops blocking
+// and returns ctx.Err() once 'ctx' is done.
+func (m *SyncableVGPUConfig) GetWithContext(ctx context.Context) (string, error) {
+	stop := context.AfterFunc(ctx, func() {
+		m.mutex.Lock()
+		defer m.mutex.Unlock()
+		m.cond.Broadcast()
+	})
+	defer stop()
+
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
 	if m.lastRead == m.current {
 		m.cond.Wait()
 	}
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	m.lastRead = m.current
+	return m.lastRead, nil
+}
+
+// Resend re-sends the current value of the config (or 'fallback' if it is unset),
+// so that the next call to Get() returns it even though it has not changed.
+func (m *SyncableVGPUConfig) Resend(fallback string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.current == "" {
+		m.current = fallback
+	}
+	m.lastRead = ""
+	m.cond.Broadcast()
+}
+
+// Peek gets the current value of the config without blocking or marking it as read.
+func (m *SyncableVGPUConfig) Peek() string {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.current
+}
+
+// GetCurrent gets the current value of the config without blocking.
+// A subsequent call to Get() will block until a new value is Set().
+func (m *SyncableVGPUConfig) GetCurrent() string {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
 	m.lastRead = m.current
 	return m.lastRead
 }
@@ -132,6 +225,12 @@ func main() {
 			Destination: &namespaceFlag,
 			EnvVars:     []string{"NAMESPACE"},
 		},
+		&cli.StringSliceFlag{
+			Name:        "extra-namespaces",
+			Usage:       "additional namespaces in which GPU components are deployed, waited on alongside <namespace> when shutting them down (repeated or comma-separated)",
+			Destination: &extraNamespacesFlag,
+			EnvVars:     []string{"EXTRA_NAMESPACES"},
+		},
 		&cli.StringFlag{
 			Name:        "config-file",
 			Aliases:     []string{"f"},
@@ -140,6 +239,26 @@ func main() {
 			Destination: &configFileFlag,
 			EnvVars:     []string{"CONFIG_FILE"},
 		},
+		&cli.StringFlag{
+			Name:        "config-dir",
+			Value:       "",
+			Usage:       "the path to a directory of vGPU configuration files, used instead of <config-file>; each config is selected as '<file>.<config>', so file names must not contain '.' before the extension",
+			Destination: &configDirFlag,
+			EnvVars:     []string{"CONFIG_DIR"},
+		},
+		&cli.StringFlag{
+			Name:        "config-configmap",
+			Value:       "",
+			Usage:       "a <namespace>/<name> reference to a ConfigMap holding the vGPU configuration file in its 'config.yaml' key, used instead of <config-file>",
+			Destination: &configConfigMapFlag,
+			EnvVars:     []string{"CONFIG_CONFIGMAP"},
+		},
+		&cli.BoolFlag{
+			Name:        "overwrite-on-conflict",
+			Usage:       "when merging the files in <config-dir>, let later files overwrite configs with the same name instead of failing",
+			Destination: &overwriteOnConflictFlag,
+			EnvVars:     []string{"OVERWRITE_ON_CONFLICT"},
+		},
 		&cli.StringFlag{
 			Name:        "default-vgpu-config",
 			Aliases:     []string{"d"},
@@ -148,9 +267,93 @@ func main() {
 			Destination: &defaultVGPUConfigFlag,
 			EnvVars:     []string{"DEFAULT_VGPU_CONFIG"},
 		},
+		&cli.DurationFlag{
+			Name:        "pending-timeout",
+			Value:       10 * time.Minute,
+			Usage:       "the time after which a vGPU config state left 'pending' at startup is treated as failed and re-applied",
+			Destination: &pendingTimeoutFlag,
+			EnvVars:     []string{"PENDING_TIMEOUT"},
+		},
+		&cli.DurationFlag{
+			Name:        "force-apply-after",
+			Value:       0,
+			Usage:       "force the vGPU config to be re-applied at startup if it was last successfully applied longer ago than this (0 means never)",
+			Destination: &forceApplyAfterFlag,
+			EnvVars:     []string{"FORCE_APPLY_AFTER"},
+		},
+		&cli.DurationFlag{
+			Name:        "vgpu-config-timeout",
+			Value:       0,
+			Usage:       "the maximum time to spend applying a single vGPU config before it is considered failed (0 means no timeout)",
+			Destination: &vgpuConfigTimeoutFlag,
+			EnvVars:     []string{"VGPU_CONFIG_TIMEOUT"},
+		},
+		&cli.StringFlag{
+			Name:        "label-prefix",
+			Value:       defaultLabelPrefix,
+			Usage:       "the prefix of the node labels and annotations used to select and report the vGPU config (e.g. <label-prefix>.config)",
+			Destination: &labelPrefixFlag,
+			EnvVars:     []string{"LABEL_PREFIX"},
+		},
+		&cli.BoolFlag{
+			Name:        "use-annotation",
+			Value:       false,
+			Usage:       "read the desired vGPU config from, and record its state in, node annotations instead of labels",
+			Destination: &useAnnotationFlag,
+			EnvVars:     []string{"USE_ANNOTATION"},
+		},
+		&cli.StringFlag{
+			Name:        "state-configmap",
+			Value:       "",
+			Usage:       "the name of a ConfigMap in <namespace> to also record the vGPU config state of the node in",
+			Destination: &stateConfigMapFlag,
+			EnvVars:     []string{"STATE_CONFIGMAP"},
+		},
+		&cli.StringFlag{
+			Name:        "temp-dir",
+			Value:       os.TempDir(),
+			Usage:       "the directory in which to create temporary files, such as the merged <config-dir> config",
+			Destination: &tempDirFlag,
+			EnvVars:     []string{"TEMP_DIR"},
+		},
+		&cli.BoolFlag{
+			Name:        "cleanup-temp-files",
+			Value:       true,
+			Usage:       "remove temporary files once they are no longer needed",
+			Destination: &cleanupTempFilesFlag,
+			EnvVars:     []string{"CLEANUP_TEMP_FILES"},
+		},
+		&cli.StringFlag{
+			Name:        "config-cache-dir",
+			Value:       "",
+			Usage:       "a directory in which to cache the parsed files of <config-dir>, so unchanged files are not re-parsed on every config change",
+			Destination: &configCacheDirFlag,
+			EnvVars:     []string{"CONFIG_CACHE_DIR"},
+		},
+		&cli.BoolFlag{
+			Name:        "dry-run",
+			Usage:       "only log the changes each selected vGPU config would make, without applying it or updating node labels",
+			Destination: &dryRunFlag,
+			EnvVars:     []string{"DRY_RUN"},
+		},
+		&cli.StringFlag{
+			Name:        "metrics-addr",
+			Value:       ":9090",
+			Usage:       "the address to serve Prometheus metrics on at /metrics (an empty string disables the metrics server)",
+			Destination: &metricsAddrFlag,
+			EnvVars:     []string{"METRICS_ADDR"},
+		},
+		&cli.StringFlag{
+			Name:        "status-file",
+			Value:       "",
+			Usage:       "the path of a JSON file to also record the vGPU config state of the node in, for monitoring agents that cannot scrape metrics",
+			Destination: &statusFileFlag,
+			EnvVars:     []string{"STATUS_FILE"},
+		},
 	}
 
-	log.Infof("version: %s", c.Version)
+	log.Infof("Starting %s version=%s, commit=%s, build-date=%s, go=%s, platform=%s/%s",
+		c.Name, info.GetVersion(), info.GetCommit(), info.GetBuildDate(), runtime.Version(), runtime.GOOS, runtime.GOARCH)
 
 	err := c.Run(os.Args)
 	if err != nil {
@@ -167,16 +370,108 @@ func validateFlags(c *cli.Context) error {
 	if namespaceFlag == "" {
 		return fmt.Errorf("invalid <namespace> flag: must not be empty string")
 	}
-	if configFileFlag == "" {
+	if errs := validation.IsDNS1123Label(namespaceFlag); len(errs) > 0 {
+		return fmt.Errorf("invalid <namespace> flag '%s': must be a valid DNS label: %s", namespaceFlag, strings.Join(errs, "; "))
+	}
+	for _, ns := range extraNamespacesFlag.Value() {
+		if errs := validation.IsDNS1123Label(ns); len(errs) > 0 {
+			return fmt.Errorf("invalid <extra-namespaces> flag '%s': must be a valid DNS label: %s", ns, strings.Join(errs, "; "))
+		}
+	}
+	if configFileFlag == "" && configDirFlag == "" && configConfigMapFlag == "" {
 		return fmt.Errorf("invalid <config-file> flag: must not be empty string")
 	}
+	if configFileFlag != "" && configDirFlag != "" {
+		return fmt.Errorf("invalid <config-dir> flag: must not be set together with <config-file>")
+	}
+	if configConfigMapFlag != "" && (configFileFlag != "" || configDirFlag != "") {
+		return fmt.Errorf("invalid <config-configmap> flag: must not be set together with <config-file> or <config-dir>")
+	}
+	if configConfigMapFlag != "" {
+		namespace, name, err := parseConfigMapRef(configConfigMapFlag)
+		if err != nil {
+			return fmt.Errorf("invalid <config-configmap> flag: %v", err)
+		}
+		if errs := validation.IsDNS1123Label(namespace); len(errs) > 0 {
+			return fmt.Errorf("invalid <config-configmap> flag: invalid namespace '%s': %s", namespace, strings.Join(errs, "; "))
+		}
+		if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+			return fmt.Errorf("invalid <config-configmap> flag: invalid name '%s': %s", name, strings.Join(errs, "; "))
+		}
+	}
 	if defaultVGPUConfigFlag == "" {
 		return fmt.Errorf("invalid <default-vgpu-config> flag: must not be empty string")
 	}
+	if err := validateLabelPrefix(labelPrefixFlag); err != nil {
+		return fmt.Errorf("invalid <label-prefix> flag: %v", err)
+	}
+	setLabelNames(labelPrefixFlag)
+	if err := assertWritableDir(tempDirFlag); err != nil {
+		return fmt.Errorf("invalid <temp-dir> flag: %v", err)
+	}
+	if statusFileFlag != "" {
+		if err := assertWritableDir(filepath.Dir(statusFileFlag)); err != nil {
+			return fmt.Errorf("invalid <status-file> flag: %v", err)
+		}
+	}
+	if configCacheDirFlag != "" {
+		if err := assertWritableDir(configCacheDirFlag); err != nil {
+			return fmt.Errorf("invalid <config-cache-dir> flag: %v", err)
+		}
+	}
 	return nil
 }
 
+// setLabelNames derives the names of the node labels and annotations managed
+// by the vGPU Device Manager from 'prefix'.
+func setLabelNames(prefix string) {
+	vGPUConfigLabel, vGPUConfigStateLabel, lastAppliedAnnotation = labelNames(prefix)
+}
+
+// labelNames returns the names of the config label, the config state label and
+// the last-applied annotation for a given label prefix.
+func labelNames(prefix string) (string, string, string) {
+	return prefix + ".config", prefix + ".config.state", prefix + ".config.last-applied"
+}
+
+// validateLabelPrefix checks that every label and annotation name derived from 'prefix' is a valid qualified name.
+func validateLabelPrefix(prefix string) error {
+	if prefix == "" {
+		return fmt.Errorf("must not be empty string")
+	}
+	config, state, lastApplied := labelNames(prefix)
+	for _, name := range []string{config, state, lastApplied} {
+		if errs := validation.IsQualifiedName(name); len(errs) > 0 {
+			return fmt.Errorf("'%s' is not a valid label name for prefix '%s': %s", name, prefix, strings.Join(errs, "; "))
+		}
+	}
+	return nil
+}
+
+// assertWritableDir checks that 'dir' is an existing directory that files can be created in.
+func assertWritableDir(dir string) error {
+	fi, err := os.Stat(dir)
+	if err != nil {
+		return err
+	}
+	if !fi.IsDir() {
+		return fmt.Errorf("%s is not a directory", dir)
+	}
+
+	f, err := os.CreateTemp(dir, ".write-test-*")
+	if err != nil {
+		return fmt.Errorf("%s is not writable: %v", dir, err)
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
+
 func start(c *cli.Context) error {
+	startTime = time.Now()
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer cancel()
+
 	clientConfig, err := clientcmd.BuildConfigFromFlags("", kubeconfigFlag)
 	if err != nil {
 		return fmt.Errorf("error building kubernetes clientcmd config: %s", err)
@@ -187,54 +482,132 @@ func start(c *cli.Context) error {
 		return fmt.Errorf("error building kubernetes clientset from config: %s", err)
 	}
 
+	recorder, stopRecorder := newEventRecorder(clientset)
+	defer stopRecorder()
+	eventRecorder = recorder
+
+	vgpuMetrics := metrics.New()
+	if metricsAddrFlag != "" {
+		startMetricsServer(metricsAddrFlag, vgpuMetrics.Handler())
+	}
+
 	vGPUConfig := NewSyncableVGPUConfig()
 
-	stop := continuouslySyncVGPUConfigChanges(clientset, vGPUConfig)
+	stop, err := continuouslySyncVGPUConfigChanges(clientset, vGPUConfig)
+	if err != nil {
+		return fmt.Errorf("unable to sync vGPU config label: %v", err)
+	}
 	defer close(stop)
 
-	// Apply initial vGPU configuration. If the node is not labeled with an
-	// explicit config, apply the default configuration.
-	selectedConfig, err := getNodeLabelValue(clientset, vGPUConfigLabel)
-	if err != nil {
-		return fmt.Errorf("unable to get vGPU config label: %v", err)
+	source := newConfigSource(clientset)
+	if configConfigMapFlag != "" {
+		namespace, name, _ := parseConfigMapRef(configConfigMapFlag)
+		configMapStop, err := continuouslySyncConfigMapChanges(clientset, namespace, name, vGPUConfig)
+		if err != nil {
+			return fmt.Errorf("unable to sync vGPU config ConfigMap: %v", err)
+		}
+		defer close(configMapStop)
+	}
+	if configDirFlag != "" {
+		configDirStop, err := continuouslySyncConfigDirChanges(configDirFlag, vGPUConfig)
+		if err != nil {
+			return fmt.Errorf("unable to watch vGPU config directory: %v", err)
+		}
+		defer close(configDirStop)
+	}
+	if configFileFlag != "" {
+		configFileStop, err := continuouslySyncConfigFileChanges(configFileFlag, vGPUConfig)
+		if err != nil {
+			return fmt.Errorf("unable to watch vGPU config file: %v", err)
+		}
+		defer close(configFileStop)
 	}
 
+	// Apply initial vGPU configuration. The informer has already synced, so
+	// the current value of the label (if any) has been delivered to vGPUConfig.
+	// If the node is not labeled with an explicit config, apply the default
+	// configuration.
+	selectedConfig := vGPUConfig.GetCurrent()
 	if selectedConfig == "" {
 		log.Infof("No vGPU config specified for node. Proceeding with default config: %s", defaultVGPUConfigFlag)
 		selectedConfig = defaultVGPUConfigFlag
-	} else {
-		selectedConfig = vGPUConfig.Get()
+	}
+
+	// If a previous instance left the node in the 'pending' state for too
+	// long (e.g. it crashed mid-apply), treat that apply as failed and force
+	// the config to be re-applied so that any paused operands get restarted.
+	force, err := isPendingStateExpired(clientset)
+	if err != nil {
+		return fmt.Errorf("unable to check vGPU config state: %v", err)
+	}
+
+	// A node reboot discards all vGPU devices while leaving the state label
+	// untouched, so optionally re-apply the config if the last successful
+	// apply is older than the configured threshold.
+	if !force && forceApplyAfterFlag > 0 {
+		force, err = isLastApplyExpired(clientset)
+		if err != nil {
+			return fmt.Errorf("unable to check last applied time: %v", err)
+		}
 	}
 
 	log.Infof("Updating to vGPU config: %s", selectedConfig)
-	err = updateConfig(clientset, selectedConfig)
+	applyStart := time.Now()
+	err = updateConfigWithTimeout(clientset, source, selectedConfig, force)
 	if err != nil {
 		log.Errorf("Failed to apply vGPU config: %v", err)
 	} else {
 		log.Infof("Successfully updated to vGPU config: %s", selectedConfig)
 	}
-	vGPUConfigStateValue := getVGPUConfigStateValue(err)
-	log.Infof("Setting node label: %s=%s", vGPUConfigStateLabel, vGPUConfigStateValue)
-	_ = setNodeLabelValue(clientset, vGPUConfigStateLabel, vGPUConfigStateValue)
+	if !dryRunFlag {
+		vgpuMetrics.RecordConfigApply(selectedConfig, time.Since(applyStart), err)
+		_ = setVGPUConfigState(clientset, selectedConfig, getVGPUConfigStateValue(err), err)
+	}
 
 	// Watch for configuration changes
 	for {
-		log.Infof("Waiting for change to '%s' label", vGPUConfigLabel)
-		value := vGPUConfig.Get()
+		log.Infof("Waiting for change to '%s' %s", vGPUConfigLabel, nodeMetadataKind())
+		value, err := vGPUConfig.GetWithContext(ctx)
+		if err != nil {
+			log.Infof("Shutting down: %v", err)
+			return nil
+		}
 		log.Infof("Updating to vGPU config: %s", value)
-		err = updateConfig(clientset, value)
+		applyStart := time.Now()
+		err = updateConfigWithTimeout(clientset, source, value, false)
 		if err != nil {
 			log.Errorf("Failed to apply vGPU config: %v", err)
 		} else {
 			log.Infof("Successfully updated to vGPU config: %s", value)
 		}
-		vGPUConfigStateValue = getVGPUConfigStateValue(err)
-		log.Infof("Setting node label: %s=%s", vGPUConfigStateLabel, vGPUConfigStateValue)
-		_ = setNodeLabelValue(clientset, vGPUConfigStateLabel, vGPUConfigStateValue)
+		if !dryRunFlag {
+			vgpuMetrics.RecordConfigApply(value, time.Since(applyStart), err)
+			_ = setVGPUConfigState(clientset, value, getVGPUConfigStateValue(err), err)
+		}
+	}
+}
+
+// startMetricsServer serves 'handler' at /metrics on 'addr' in the background.
+// A failure to serve is logged but does not stop the daemon.
+func startMetricsServer(addr string, handler http.Handler) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", handler)
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
 	}
+
+	go func() {
+		log.Infof("Serving metrics on %s/metrics", addr)
+		err := server.ListenAndServe()
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Errorf("Error serving metrics: %v", err)
+		}
+	}()
 }
 
-func continuouslySyncVGPUConfigChanges(clientset *kubernetes.Clientset, vGPUConfig *SyncableVGPUConfig) chan struct{} {
+func continuouslySyncVGPUConfigChanges(clientset kubernetes.Interface, vGPUConfig *SyncableVGPUConfig) (chan struct{}, error) {
 	listWatch := cache.NewListWatchFromClient(
 		clientset.CoreV1().RESTClient(),
 		resourceNodes,
@@ -247,13 +620,13 @@ func continuouslySyncVGPUConfigChanges(clientset *kubernetes.Clientset, vGPUConf
 		ObjectType:    &corev1.Node{},
 		Handler: cache.ResourceEventHandlerFuncs{
 			AddFunc: func(obj interface{}) {
-				vGPUConfig.Set(obj.(*corev1.Node).Labels[vGPUConfigLabel])
+				vGPUConfig.Set(getNodeMetadataValue(obj.(*corev1.Node), vGPUConfigLabel))
 			},
 			UpdateFunc: func(oldObj, newObj interface{}) {
-				oldLabel := oldObj.(*corev1.Node).Labels[vGPUConfigLabel]
-				newLabel := newObj.(*corev1.Node).Labels[vGPUConfigLabel]
-				if oldLabel != newLabel {
-					vGPUConfig.Set(newLabel)
+				oldValue := getNodeMetadataValue(oldObj.(*corev1.Node), vGPUConfigLabel)
+				newValue := getNodeMetadataValue(newObj.(*corev1.Node), vGPUConfigLabel)
+				if oldValue != newValue {
+					vGPUConfig.Set(newValue)
 				}
 			},
 		},
@@ -262,48 +635,86 @@ func continuouslySyncVGPUConfigChanges(clientset *kubernetes.Clientset, vGPUConf
 	_, controller := cache.NewInformerWithOptions(opts)
 	stop := make(chan struct{})
 	go controller.Run(stop)
-	return stop
+	if !cache.WaitForCacheSync(stop, controller.HasSynced) {
+		close(stop)
+		return nil, fmt.Errorf("failed to wait for node informer to sync")
+	}
+	return stop, nil
 }
 
-func updateConfig(clientset *kubernetes.Clientset, selectedConfig string) error {
+// updateConfigWithTimeout runs updateConfig, bounded by <vgpu-config-timeout> if set.
+// When the timeout expires, any nvidia-vgpu-dm subprocess still running is killed.
+func updateConfigWithTimeout(clientset kubernetes.Interface, source ConfigSource, selectedConfig string, force bool) error {
+	ctx, cancel := context.Background(), context.CancelFunc(func() {})
+	if vgpuConfigTimeoutFlag > 0 {
+		ctx, cancel = context.WithTimeout(ctx, vgpuConfigTimeoutFlag)
+	}
+	defer cancel()
+
+	err := updateConfig(ctx, clientset, source, selectedConfig, force)
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("timed out after %v: %v", vgpuConfigTimeoutFlag, err)
+	}
+	return err
+}
+
+func updateConfig(ctx context.Context, clientset kubernetes.Interface, source ConfigSource, selectedConfig string, force bool) error {
+	configFile, cleanup, err := source.GetConfigFile()
+	if err != nil {
+		return fmt.Errorf("unable to get the vGPU configuration file: %v", err)
+	}
+	defer cleanup()
 
 	log.Info("Asserting that the requested configuration is present in the configuration file")
-	err := assertValidConfig(selectedConfig)
+	err = assertValidConfig(ctx, configFile, selectedConfig)
 	if err != nil {
 		return fmt.Errorf("unable to validate the selected vGPU configuration")
 	}
 
-	log.Info("Checking if the selected vGPU device configuration is currently applied or not")
-	err = assertConfig(selectedConfig)
-	if err == nil {
-		return nil
+	log.Info("Checking that the selected vGPU device configuration can be applied to the GPUs on the node")
+	err = previewConfig(ctx, configFile, selectedConfig)
+	if err != nil {
+		return fmt.Errorf("selected vGPU configuration cannot be applied to the GPUs on the node: %v", err)
+	}
+
+	if !force {
+		log.Info("Checking if the selected vGPU device configuration is currently applied or not")
+		err = assertConfig(ctx, configFile, selectedConfig)
+		if err == nil {
+			return nil
+		}
 	}
 
-	err = getNodeStateLabels(clientset)
+	if dryRunFlag {
+		log.Info("Logging the changes the selected vGPU device configuration would make without applying it")
+		return dryRunConfig(ctx, configFile, selectedConfig)
+	}
+
+	operands := &operandState{}
+	err = getNodeStateLabels(clientset, operands)
 	if err != nil {
 		return fmt.Errorf("unable to get node state labels: %v", err)
 	}
 
-	log.Infof("Setting node label: %s=pending", vGPUConfigStateLabel)
-	err = setNodeLabelValue(clientset, vGPUConfigStateLabel, "pending")
+	err = setVGPUConfigState(clientset, selectedConfig, "pending", nil)
 	if err != nil {
 		return fmt.Errorf("error setting vGPU config state label: %v", err)
 	}
 
 	log.Info("Shutting down all GPU operands in Kubernetes by disabling their component-specific nodeSelector labels")
-	err = shutdownGPUOperands(clientset)
+	err = shutdownGPUOperands(clientset, operands)
 	if err != nil {
 		return fmt.Errorf("unable to shutdown gpu operands: %v", err)
 	}
 
 	log.Info("Applying the selected vGPU device configuration to the node")
-	err = applyConfig(selectedConfig)
+	err = applyConfig(ctx, configFile, selectedConfig)
 	if err != nil {
 		return fmt.Errorf("unable to apply config '%s': %v", selectedConfig, err)
 	}
 
 	log.Info("Restarting all GPU operands previously shutdown in Kubernetes by enabling their component-specific nodeSelector labels")
-	err = rescheduleGPUOperands(clientset)
+	err = rescheduleGPUOperands(clientset, operands)
 	if err != nil {
 		return fmt.Errorf("unable to reschedule gpu operands: %v", err)
 	}
@@ -311,44 +722,78 @@ func updateConfig(clientset *kubernetes.Clientset, selectedConfig string) error
 	return nil
 }
 
-func assertValidConfig(config string) error {
+func assertValidConfig(ctx context.Context, configFile, config string) error {
 	args := []string{
+		"-f", configFile,
+		"-c", config,
 		"assert",
 		"--valid-config",
-		"-f", configFileFlag,
+	}
+	cmd := exec.CommandContext(ctx, cliName, args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+func previewConfig(ctx context.Context, configFile, config string) error {
+	args := []string{
+		"-f", configFile,
 		"-c", config,
+		"apply",
+		"--config-preview",
 	}
-	cmd := exec.Command(cliName, args...)
+	cmd := exec.CommandContext(ctx, cliName, args...)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	return cmd.Run()
 }
 
-func assertConfig(config string) error {
+func assertConfig(ctx context.Context, configFile, config string) error {
 	args := []string{
-		"assert",
-		"-f", configFileFlag,
+		"-f", configFile,
 		"-c", config,
+		"assert",
 	}
-	cmd := exec.Command(cliName, args...)
+	cmd := exec.CommandContext(ctx, cliName, args...)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	return cmd.Run()
 }
 
-func applyConfig(config string) error {
+func applyConfig(ctx context.Context, configFile, config string) error {
 	args := []string{
 		"-d",
-		"apply",
-		"-f", configFileFlag,
+		"-f", configFile,
 		"-c", config,
+		"apply",
 	}
-	cmd := exec.Command(cliName, args...)
+	cmd := exec.CommandContext(ctx, cliName, args...)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	return cmd.Run()
 }
 
+// dryRunConfig runs 'apply --dry-run' for the selected config. Its exit code
+// signalling that changes would be made is not treated as an error.
+func dryRunConfig(ctx context.Context, configFile, config string) error {
+	args := []string{
+		"-f", configFile,
+		"-c", config,
+		"apply",
+		"--dry-run",
+	}
+	cmd := exec.CommandContext(ctx, cliName, args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	err := cmd.Run()
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == dryRunChangesPendingExitCode {
+		return nil
+	}
+	return err
+}
+
 func getVGPUConfigStateValue(err error) string {
 	if err != nil {
 		return "failed"
@@ -356,7 +801,7 @@ func getVGPUConfigStateValue(err error) string {
 	return "success"
 }
 
-func getNodeStateLabels(clientset *kubernetes.Clientset) error {
+func getNodeStateLabels(clientset kubernetes.Interface, operands *operandState) error {
 	node, err := clientset.CoreV1().Nodes().Get(context.TODO(), nodeNameFlag, metav1.GetOptions{})
 	if err != nil {
 		return fmt.Errorf("unable to get node object: %v", err)
@@ -364,17 +809,17 @@ func getNodeStateLabels(clientset *kubernetes.Clientset) error {
 	labels := node.GetLabels()
 
 	log.Infof("Getting current value of '%s' node label", pluginStateLabel)
-	pluginDeployed = labels[pluginStateLabel]
-	log.Infof("Current value of '%s=%s'", pluginStateLabel, pluginDeployed)
+	operands.pluginDeployed = labels[pluginStateLabel]
+	log.Infof("Current value of '%s=%s'", pluginStateLabel, operands.pluginDeployed)
 
 	log.Infof("Getting current value of '%s' node label", validatorStateLabel)
-	validatorDeployed = labels[validatorStateLabel]
-	log.Infof("Current value of '%s=%s'", validatorStateLabel, validatorDeployed)
+	operands.validatorDeployed = labels[validatorStateLabel]
+	log.Infof("Current value of '%s=%s'", validatorStateLabel, operands.validatorDeployed)
 
 	return nil
 }
 
-func shutdownGPUOperands(clientset *kubernetes.Clientset) error {
+func shutdownGPUOperands(clientset kubernetes.Interface, operands *operandState) error {
 	// shutdown components by updating their respective state labels.
 	node, err := clientset.CoreV1().Nodes().Get(context.TODO(), nodeNameFlag, metav1.GetOptions{})
 	if err != nil {
@@ -382,10 +827,10 @@ func shutdownGPUOperands(clientset *kubernetes.Clientset) error {
 	}
 	labels := node.GetLabels()
 
-	pluginDeployed = maybeSetPaused(pluginDeployed)
-	validatorDeployed = maybeSetPaused(validatorDeployed)
-	labels[pluginStateLabel] = pluginDeployed
-	labels[validatorStateLabel] = validatorDeployed
+	operands.pluginDeployed = maybeSetPaused(operands.pluginDeployed)
+	operands.validatorDeployed = maybeSetPaused(operands.validatorDeployed)
+	labels[pluginStateLabel] = operands.pluginDeployed
+	labels[validatorStateLabel] = operands.validatorDeployed
 
 	node.SetLabels(labels)
 	_, err = clientset.CoreV1().Nodes().Update(context.TODO(), node, metav1.UpdateOptions{})
@@ -395,7 +840,7 @@ func shutdownGPUOperands(clientset *kubernetes.Clientset) error {
 
 	// wait for pods to be deleted
 	log.Infof("Waiting for sandbox-device-plugin to shutdown")
-	err = waitForPodDeletion(clientset, metav1.ListOptions{
+	err = waitForPodDeletion(clientset, operandNamespaces(), metav1.ListOptions{
 		FieldSelector: fmt.Sprintf("spec.nodeName=%s", nodeNameFlag),
 		LabelSelector: "app=nvidia-sandbox-device-plugin-daemonset",
 	})
@@ -404,7 +849,7 @@ func shutdownGPUOperands(clientset *kubernetes.Clientset) error {
 	}
 
 	log.Infof("Waiting for sandbox-validator to shutdown")
-	err = waitForPodDeletion(clientset, metav1.ListOptions{
+	err = waitForPodDeletion(clientset, operandNamespaces(), metav1.ListOptions{
 		FieldSelector: fmt.Sprintf("spec.nodeName=%s", nodeNameFlag),
 		LabelSelector: "app=nvidia-sandbox-validator",
 	})
@@ -415,22 +860,46 @@ func shutdownGPUOperands(clientset *kubernetes.Clientset) error {
 	return nil
 }
 
-func waitForPodDeletion(clientset *kubernetes.Clientset, listOpts metav1.ListOptions) error {
+// operandNamespaces returns the namespaces in which GPU operand pods may be running.
+func operandNamespaces() []string {
+	return append([]string{namespaceFlag}, extraNamespacesFlag.Value()...)
+}
+
+// waitForPodDeletion waits until no pods matching 'listOpts' remain in any of 'namespaces'.
+// The namespaces are polled concurrently, and errors from all of them are reported.
+func waitForPodDeletion(clientset kubernetes.Interface, namespaces []string, listOpts metav1.ListOptions) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 120*time.Second)
 	defer cancel()
-	pollFunc := func(context.Context) (bool, error) {
-		podList, err := clientset.CoreV1().Pods(namespaceFlag).List(ctx, listOpts)
-		if apierrors.IsNotFound(err) {
-			log.Infof("Pod was already deleted")
-			return true, nil
+	pollFunc := func(ctx context.Context) (bool, error) {
+		var g errgroup.Group
+		remaining := make([]int, len(namespaces))
+		errs := make([]error, len(namespaces))
+		for i, namespace := range namespaces {
+			g.Go(func() error {
+				podList, err := clientset.CoreV1().Pods(namespace).List(ctx, listOpts)
+				if apierrors.IsNotFound(err) {
+					log.Infof("Pod was already deleted in namespace '%s'", namespace)
+					return nil
+				}
+				if err != nil {
+					errs[i] = fmt.Errorf("namespace '%s': %v", namespace, err)
+					return nil
+				}
+				remaining[i] = len(podList.Items)
+				return nil
+			})
 		}
-		if err != nil {
+		_ = g.Wait()
+
+		if err := errors.Join(errs...); err != nil {
 			return false, err
 		}
-		if len(podList.Items) == 0 {
-			return true, nil
+		for _, n := range remaining {
+			if n > 0 {
+				return false, nil
+			}
 		}
-		return false, nil
+		return true, nil
 	}
 
 	err := wait.PollUntilContextCancel(ctx, 1*time.Second, true, pollFunc)
@@ -441,15 +910,15 @@ func waitForPodDeletion(clientset *kubernetes.Clientset, listOpts metav1.ListOpt
 	return nil
 }
 
-func rescheduleGPUOperands(clientset *kubernetes.Clientset) error {
+func rescheduleGPUOperands(clientset kubernetes.Interface, operands *operandState) error {
 	node, err := clientset.CoreV1().Nodes().Get(context.TODO(), nodeNameFlag, metav1.GetOptions{})
 	if err != nil {
 		return fmt.Errorf("unable to get node object: %v", err)
 	}
 	labels := node.GetLabels()
 
-	labels[pluginStateLabel] = maybeSetTrue(pluginDeployed)
-	labels[validatorStateLabel] = maybeSetTrue(validatorDeployed)
+	labels[pluginStateLabel] = maybeSetTrue(operands.pluginDeployed)
+	labels[validatorStateLabel] = maybeSetTrue(operands.validatorDeployed)
 
 	node.SetLabels(labels)
 	_, err = clientset.CoreV1().Nodes().Update(context.TODO(), node, metav1.UpdateOptions{})
@@ -474,33 +943,242 @@ func maybeSetTrue(currentValue string) string {
 	return "true"
 }
 
-func getNodeLabelValue(clientset *kubernetes.Clientset, label string) (string, error) {
+// vGPUConfigState holds the last recorded vGPU config state of the node,
+// along with the time it was recorded (zero if unknown).
+type vGPUConfigState struct {
+	value    string
+	modified time.Time
+}
+
+// getVGPUConfigState returns the last recorded vGPU config state of the node.
+// It is read from the state ConfigMap if one is configured, or else from the node label.
+func getVGPUConfigState(clientset kubernetes.Interface) (vGPUConfigState, error) {
+	if stateConfigMapFlag != "" {
+		return getStateConfigMapState(clientset)
+	}
+
 	node, err := clientset.CoreV1().Nodes().Get(context.TODO(), nodeNameFlag, metav1.GetOptions{})
 	if err != nil {
-		return "", fmt.Errorf("unable to get node object: %v", err)
+		return vGPUConfigState{}, fmt.Errorf("unable to get node object: %v", err)
 	}
 
-	value, ok := node.Labels[label]
-	if !ok {
-		return "", nil
+	state := vGPUConfigState{
+		value: getNodeMetadataValue(node, vGPUConfigStateLabel),
+	}
+	if modified, ok := getMetadataLastModifiedTime(node, vGPUConfigStateLabel); ok {
+		state.modified = modified
 	}
 
-	return value, nil
+	return state, nil
 }
 
-func setNodeLabelValue(clientset *kubernetes.Clientset, label, value string) error {
+// setVGPUConfigState records the vGPU config state of the node in the node
+// label and, if one is configured, in the state ConfigMap.
+func setVGPUConfigState(clientset kubernetes.Interface, selectedConfig, value string, applyErr error) error {
+	recordVGPUConfigStateEvent(selectedConfig, value, applyErr)
+
+	if statusFileFlag != "" {
+		err := writeStatusFile(statusFileFlag, nodeNameFlag, selectedConfig, value, applyErr)
+		if err != nil {
+			log.Warnf("Unable to write status file '%s': %v", statusFileFlag, err)
+		}
+	}
+
+	log.Infof("Setting node %s: %s=%s", nodeMetadataKind(), vGPUConfigStateLabel, value)
+	err := setNodeMetadataValue(clientset, vGPUConfigStateLabel, value)
+	if err != nil {
+		return err
+	}
+
+	if value == "success" {
+		lastApplied := time.Now().UTC().Format(time.RFC3339)
+		log.Infof("Setting node annotation: %s=%s", lastAppliedAnnotation, lastApplied)
+		err = setNodeAnnotationValue(clientset, lastAppliedAnnotation, lastApplied)
+		if err != nil {
+			return err
+		}
+	}
+
+	if stateConfigMapFlag == "" {
+		return nil
+	}
+
+	log.Infof("Updating state configmap: %s/%s", namespaceFlag, stateConfigMapFlag)
+	return updateStateConfigMap(clientset, selectedConfig, value, applyErr)
+}
+
+// isPendingStateExpired checks if the vGPU config state was left 'pending'
+// for longer than the pending timeout before this instance started.
+func isPendingStateExpired(clientset kubernetes.Interface) (bool, error) {
+	state, err := getVGPUConfigState(clientset)
+	if err != nil {
+		return false, err
+	}
+
+	if state.value != "pending" {
+		return false, nil
+	}
+
+	if state.modified.IsZero() {
+		log.Warnf("Unable to determine when the vGPU config state was last modified, treating 'pending' state as failed")
+		return true, nil
+	}
+
+	age := startTime.Sub(state.modified)
+	if age < pendingTimeoutFlag {
+		log.Infof("Found vGPU config state 'pending' set %v ago, within the pending timeout of %v", age, pendingTimeoutFlag)
+		return false, nil
+	}
+
+	log.Warnf("Found vGPU config state 'pending' set %v ago, treating previous vGPU config apply as failed", age)
+	return true, nil
+}
+
+// isLastApplyExpired checks if the vGPU config was last successfully applied
+// longer than the force-apply-after duration before this instance started.
+// If the node has never been annotated with a last applied time, the node's
+// creation time is used instead.
+func isLastApplyExpired(clientset kubernetes.Interface) (bool, error) {
 	node, err := clientset.CoreV1().Nodes().Get(context.TODO(), nodeNameFlag, metav1.GetOptions{})
 	if err != nil {
-		return fmt.Errorf("unable to get node object: %v", err)
+		return false, fmt.Errorf("unable to get node object: %v", err)
 	}
 
-	labels := node.GetLabels()
-	labels[label] = value
-	node.SetLabels(labels)
-	_, err = clientset.CoreV1().Nodes().Update(context.TODO(), node, metav1.UpdateOptions{})
+	lastApplied := node.CreationTimestamp.Time
+	if value, ok := node.Annotations[lastAppliedAnnotation]; ok {
+		lastApplied, err = time.Parse(time.RFC3339, value)
+		if err != nil {
+			log.Warnf("Unable to parse '%s' annotation %q, forcing vGPU config to be re-applied: %v", lastAppliedAnnotation, value, err)
+			return true, nil
+		}
+	}
+
+	age := startTime.Sub(lastApplied)
+	if age < forceApplyAfterFlag {
+		log.Infof("vGPU config last applied %v ago, within the force-apply-after duration of %v", age, forceApplyAfterFlag)
+		return false, nil
+	}
+
+	log.Infof("vGPU config last applied %v ago, forcing it to be re-applied", age)
+	return true, nil
+}
+
+// getMetadataLastModifiedTime returns the last time a node label (or
+// annotation, with <use-annotation>) was modified. Neither carries timestamps
+// of its own, so this is approximated by the most recent update time of any
+// managed fields entry that owns it.
+func getMetadataLastModifiedTime(node *corev1.Node, key string) (time.Time, bool) {
+	var latest time.Time
+	found := false
+	for _, mf := range node.ManagedFields {
+		if mf.FieldsV1 == nil || mf.Time == nil {
+			continue
+		}
+		var fields struct {
+			Metadata struct {
+				Labels      map[string]json.RawMessage `json:"f:labels"`
+				Annotations map[string]json.RawMessage `json:"f:annotations"`
+			} `json:"f:metadata"`
+		}
+		if err := json.Unmarshal(mf.FieldsV1.Raw, &fields); err != nil {
+			continue
+		}
+		owned := fields.Metadata.Labels
+		if useAnnotationFlag {
+			owned = fields.Metadata.Annotations
+		}
+		if _, exists := owned["f:"+key]; !exists {
+			continue
+		}
+		if !found || mf.Time.After(latest) {
+			latest = mf.Time.Time
+			found = true
+		}
+	}
+	return latest, found
+}
+
+// nodeUpdateBackoff is the backoff used to retry node updates that fail with a transient API server error.
+var nodeUpdateBackoff = wait.Backoff{
+	Steps:    5,
+	Duration: 100 * time.Millisecond,
+	Factor:   2.0,
+	Jitter:   0.1,
+}
+
+// isTransientAPIError checks if an error from the API server is likely to go away on retry.
+func isTransientAPIError(err error) bool {
+	return apierrors.IsServerTimeout(err) || apierrors.IsServiceUnavailable(err)
+}
+
+func setNodeLabelValue(clientset kubernetes.Interface, label, value string) error {
+	err := retry.OnError(nodeUpdateBackoff, isTransientAPIError, func() error {
+		return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			node, err := clientset.CoreV1().Nodes().Get(context.TODO(), nodeNameFlag, metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+
+			labels := node.GetLabels()
+			labels[label] = value
+			node.SetLabels(labels)
+			_, err = clientset.CoreV1().Nodes().Update(context.TODO(), node, metav1.UpdateOptions{})
+			return err
+		})
+	})
 	if err != nil {
 		return fmt.Errorf("unable to update node object: %v", err)
 	}
 
 	return nil
 }
+
+// nodeMetadataKind returns the kind of node metadata the vGPU config is read
+// from and its state written to: "annotation" with <use-annotation>, or else "label".
+func nodeMetadataKind() string {
+	if useAnnotationFlag {
+		return "annotation"
+	}
+	return "label"
+}
+
+// getNodeMetadataValue returns the value of the node label (or annotation, with <use-annotation>) 'key'.
+func getNodeMetadataValue(node *corev1.Node, key string) string {
+	if useAnnotationFlag {
+		return node.Annotations[key]
+	}
+	return node.Labels[key]
+}
+
+// setNodeMetadataValue sets the node label (or annotation, with <use-annotation>) 'key' to 'value'.
+func setNodeMetadataValue(clientset kubernetes.Interface, key, value string) error {
+	if useAnnotationFlag {
+		return setNodeAnnotationValue(clientset, key, value)
+	}
+	return setNodeLabelValue(clientset, key, value)
+}
+
+// setNodeAnnotationValue sets a node annotation with a JSON merge patch, so
+// that no conflicting update of the node has to be retried.
+func setNodeAnnotationValue(clientset kubernetes.Interface, annotation, value string) error {
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{
+				annotation: value,
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("unable to build node patch: %v", err)
+	}
+
+	err = retry.OnError(nodeUpdateBackoff, isTransientAPIError, func() error {
+		_, err := clientset.CoreV1().Nodes().Patch(context.TODO(), nodeNameFlag, k8stypes.MergePatchType, patch, metav1.PatchOptions{})
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("unable to patch node object: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,246 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+const testNodeName = "test-node"
+
+func newTestClientset(t *testing.T) *fake.Clientset {
+	nodeNameFlag = testNodeName
+	t.Cleanup(func() { nodeNameFlag = "" })
+
+	return fake.NewSimpleClientset(&corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   testNodeName,
+			Labels: map[string]string{},
+		},
+	})
+}
+
+// failNodeUpdates makes the first 'failures' updates of the node fail with 'err'.
+// It returns a pointer to the number of update attempts made.
+func failNodeUpdates(clientset *fake.Clientset, failures int, err error) *int {
+	attempts := 0
+	clientset.PrependReactor("update", resourceNodes, func(action k8stesting.Action) (bool, k8sruntime.Object, error) {
+		attempts++
+		if attempts <= failures {
+			return true, nil, err
+		}
+		return false, nil, nil
+	})
+	return &attempts
+}
+
+func TestSetNodeLabelValue(t *testing.T) {
+	backoff := nodeUpdateBackoff
+	nodeUpdateBackoff.Duration = time.Millisecond
+	t.Cleanup(func() { nodeUpdateBackoff = backoff })
+
+	nodes := schema.GroupResource{Resource: resourceNodes}
+
+	testCases := []struct {
+		description      string
+		failures         int
+		err              error
+		expectedAttempts int
+		expectedErr      bool
+	}{
+		{
+			"No failures",
+			0,
+			nil,
+			1,
+			false,
+		},
+		{
+			"Conflicts are retried",
+			2,
+			apierrors.NewConflict(nodes, testNodeName, errors.New("object has been modified")),
+			3,
+			false,
+		},
+		{
+			"Server timeouts are retried",
+			2,
+			apierrors.NewServerTimeout(nodes, "update", 1),
+			3,
+			false,
+		},
+		{
+			"Service unavailable is retried",
+			2,
+			apierrors.NewServiceUnavailable("etcd leader changed"),
+			3,
+			false,
+		},
+		{
+			"Transient errors are retried at most nodeUpdateBackoff.Steps times",
+			nodeUpdateBackoff.Steps + 1,
+			apierrors.NewServiceUnavailable("etcd leader changed"),
+			nodeUpdateBackoff.Steps,
+			true,
+		},
+		{
+			"Forbidden is not retried",
+			1,
+			apierrors.NewForbidden(nodes, testNodeName, errors.New("not allowed")),
+			1,
+			true,
+		},
+		{
+			"Internal errors are not retried",
+			1,
+			apierrors.NewInternalError(errors.New("boom")),
+			1,
+			true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			clientset := newTestClientset(t)
+			attempts := failNodeUpdates(clientset, tc.failures, tc.err)
+
+			err := setNodeLabelValue(clientset, vGPUConfigStateLabel, "success")
+			require.Equal(t, tc.expectedAttempts, *attempts)
+			if tc.expectedErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			node, err := clientset.CoreV1().Nodes().Get(context.TODO(), testNodeName, metav1.GetOptions{})
+			require.NoError(t, err)
+			require.Equal(t, "success", node.Labels[vGPUConfigStateLabel])
+		})
+	}
+}
+
+func TestIsTransientAPIError(t *testing.T) {
+	nodes := schema.GroupResource{Resource: resourceNodes}
+
+	testCases := []struct {
+		description string
+		err         error
+		transient   bool
+	}{
+		{"Server timeout", apierrors.NewServerTimeout(nodes, "update", 1), true},
+		{"Service unavailable", apierrors.NewServiceUnavailable("unavailable"), true},
+		{"Conflict", apierrors.NewConflict(nodes, testNodeName, errors.New("conflict")), false},
+		{"Not found", apierrors.NewNotFound(nodes, testNodeName), false},
+		{"Forbidden", apierrors.NewForbidden(nodes, testNodeName, errors.New("forbidden")), false},
+		{"Non-API error", errors.New("connection refused"), false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			require.Equal(t, tc.transient, isTransientAPIError(tc.err))
+		})
+	}
+}
+
+func TestLabelNames(t *testing.T) {
+	testCases := []struct {
+		description string
+		prefix      string
+		names       []string
+		expectedErr bool
+	}{
+		{
+			"Default prefix",
+			defaultLabelPrefix,
+			[]string{"nvidia.com/vgpu.config", "nvidia.com/vgpu.config.state", "nvidia.com/vgpu.config.last-applied"},
+			false,
+		},
+		{
+			"Custom prefix",
+			"example.com/gpu",
+			[]string{"example.com/gpu.config", "example.com/gpu.config.state", "example.com/gpu.config.last-applied"},
+			false,
+		},
+		{
+			"Prefix without a domain",
+			"vgpu",
+			[]string{"vgpu.config", "vgpu.config.state", "vgpu.config.last-applied"},
+			false,
+		},
+		{
+			"Empty prefix",
+			"",
+			nil,
+			true,
+		},
+		{
+			"Invalid domain",
+			"Example_Domain/vgpu",
+			nil,
+			true,
+		},
+		{
+			"Empty name",
+			"nvidia.com/",
+			nil,
+			true,
+		},
+		{
+			"Too many slashes",
+			"nvidia.com/vgpu/extra",
+			nil,
+			true,
+		},
+		{
+			"Name too long",
+			"nvidia.com/" + strings.Repeat("a", 50),
+			nil,
+			true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			err := validateLabelPrefix(tc.prefix)
+			if tc.expectedErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			config, state, lastApplied := labelNames(tc.prefix)
+			require.Equal(t, tc.names, []string{config, state, lastApplied})
+
+			t.Cleanup(func() { setLabelNames(defaultLabelPrefix) })
+			setLabelNames(tc.prefix)
+			require.Equal(t, tc.names, []string{vGPUConfigLabel, vGPUConfigStateLabel, lastAppliedAnnotation})
+		})
+	}
+}
@@ -0,0 +1,116 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Keys written to the state ConfigMap. The ConfigMap is shared by all nodes,
+// so each key is prefixed with the name of the node it belongs to.
+const (
+	stateKeyState       = "vgpu.config.state"
+	stateKeyLastApplied = "vgpu.config.last-applied"
+	stateKeyLastError   = "vgpu.config.last-error"
+	stateKeyTimestamp   = "vgpu.config.state.timestamp"
+
+	stateConfigMapUpdateAttempts = 5
+)
+
+func stateConfigMapKey(key string) string {
+	return nodeNameFlag + "." + key
+}
+
+// getStateConfigMapState returns the vGPU config state recorded for this node in the state ConfigMap.
+func getStateConfigMapState(clientset kubernetes.Interface) (vGPUConfigState, error) {
+	cm, err := clientset.CoreV1().ConfigMaps(namespaceFlag).Get(context.TODO(), stateConfigMapFlag, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return vGPUConfigState{}, nil
+	}
+	if err != nil {
+		return vGPUConfigState{}, fmt.Errorf("unable to get state configmap: %v", err)
+	}
+
+	state := vGPUConfigState{
+		value: cm.Data[stateConfigMapKey(stateKeyState)],
+	}
+	if timestamp, exists := cm.Data[stateConfigMapKey(stateKeyTimestamp)]; exists {
+		modified, err := time.Parse(time.RFC3339, timestamp)
+		if err == nil {
+			state.modified = modified
+		}
+	}
+
+	return state, nil
+}
+
+// updateStateConfigMap records the vGPU config state for this node in the state ConfigMap,
+// creating the ConfigMap if it does not exist yet.
+func updateStateConfigMap(clientset kubernetes.Interface, selectedConfig, value string, applyErr error) error {
+	update := func(data map[string]string) {
+		data[stateConfigMapKey(stateKeyState)] = value
+		data[stateConfigMapKey(stateKeyTimestamp)] = time.Now().UTC().Format(time.RFC3339)
+		switch {
+		case applyErr != nil:
+			data[stateConfigMapKey(stateKeyLastError)] = applyErr.Error()
+		case value == "success":
+			data[stateConfigMapKey(stateKeyLastApplied)] = selectedConfig
+			delete(data, stateConfigMapKey(stateKeyLastError))
+		}
+	}
+
+	configMaps := clientset.CoreV1().ConfigMaps(namespaceFlag)
+
+	var err error
+	for i := 0; i < stateConfigMapUpdateAttempts; i++ {
+		var cm *corev1.ConfigMap
+		cm, err = configMaps.Get(context.TODO(), stateConfigMapFlag, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			cm = &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      stateConfigMapFlag,
+					Namespace: namespaceFlag,
+				},
+				Data: make(map[string]string),
+			}
+			update(cm.Data)
+			_, err = configMaps.Create(context.TODO(), cm, metav1.CreateOptions{})
+		} else if err == nil {
+			if cm.Data == nil {
+				cm.Data = make(map[string]string)
+			}
+			update(cm.Data)
+			_, err = configMaps.Update(context.TODO(), cm, metav1.UpdateOptions{})
+		}
+		// Other nodes update the same ConfigMap, so retry on conflicting writes.
+		if !apierrors.IsConflict(err) && !apierrors.IsAlreadyExists(err) {
+			break
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("unable to update state configmap: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,78 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// statusFile is the content of the <status-file>, describing the last vGPU config state transition of the node.
+type statusFile struct {
+	NodeName       string  `json:"node_name"`
+	SelectedConfig string  `json:"selected_config"`
+	State          string  `json:"state"`
+	Timestamp      string  `json:"timestamp"`
+	LastError      *string `json:"last_error"`
+}
+
+// writeStatusFile writes the vGPU config state to the <status-file>. The file is replaced
+// atomically by renaming a temporary file over it, so readers never see a partial write.
+func writeStatusFile(path, nodeName, selectedConfig, value string, applyErr error) error {
+	status := statusFile{
+		NodeName:       nodeName,
+		SelectedConfig: selectedConfig,
+		State:          value,
+		Timestamp:      time.Now().UTC().Format(time.RFC3339),
+	}
+	if applyErr != nil {
+		lastError := applyErr.Error()
+		status.LastError = &lastError
+	}
+
+	data, err := json.MarshalIndent(&status, "", "  ")
+	if err != nil {
+		return fmt.Errorf("unable to marshal status: %v", err)
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("unable to create temporary status file: %v", err)
+	}
+	defer os.Remove(f.Name())
+
+	_, err = f.Write(append(data, '\n'))
+	if err == nil {
+		err = f.Chmod(0644)
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("unable to write temporary status file: %v", err)
+	}
+
+	err = os.Rename(f.Name(), path)
+	if err != nil {
+		return fmt.Errorf("unable to rename temporary status file: %v", err)
+	}
+
+	return nil
+}
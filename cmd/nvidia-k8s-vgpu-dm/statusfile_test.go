@@ -0,0 +1,87 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteStatusFile(t *testing.T) {
+	testCases := []struct {
+		description string
+		state       string
+		applyErr    error
+		lastError   *string
+	}{
+		{
+			"Success",
+			"success",
+			nil,
+			nil,
+		},
+		{
+			"Failure",
+			"failed",
+			errors.New("unable to apply config"),
+			func() *string { s := "unable to apply config"; return &s }(),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "status.json")
+
+			err := writeStatusFile(path, testNodeName, "A100-4C", tc.state, tc.applyErr)
+			require.NoError(t, err)
+
+			data, err := os.ReadFile(path)
+			require.NoError(t, err)
+
+			var fields map[string]interface{}
+			require.NoError(t, json.Unmarshal(data, &fields))
+			for _, key := range []string{"node_name", "selected_config", "state", "timestamp", "last_error"} {
+				require.Contains(t, fields, key)
+			}
+
+			var status statusFile
+			require.NoError(t, json.Unmarshal(data, &status))
+			require.Equal(t, testNodeName, status.NodeName)
+			require.Equal(t, "A100-4C", status.SelectedConfig)
+			require.Equal(t, tc.state, status.State)
+			require.Equal(t, tc.lastError, status.LastError)
+
+			_, err = time.Parse(time.RFC3339, status.Timestamp)
+			require.NoError(t, err)
+
+			fi, err := os.Stat(path)
+			require.NoError(t, err)
+			require.Equal(t, os.FileMode(0644), fi.Mode().Perm())
+
+			// No temporary files are left behind
+			entries, err := os.ReadDir(filepath.Dir(path))
+			require.NoError(t, err)
+			require.Len(t, entries, 1)
+		})
+	}
+}
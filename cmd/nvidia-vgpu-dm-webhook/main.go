@@ -0,0 +1,269 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"runtime"
+
+	log "github.com/sirupsen/logrus"
+	cli "github.com/urfave/cli/v2"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/NVIDIA/vgpu-device-manager/api/spec/v1"
+	"github.com/NVIDIA/vgpu-device-manager/internal/info"
+	"github.com/NVIDIA/vgpu-device-manager/pkg/spec"
+)
+
+const (
+	defaultLabelPrefix = "nvidia.com/vgpu"
+	validatePath       = "/validate"
+	healthzPath        = "/healthz"
+
+	// maxRequestBodySize bounds the size of an AdmissionReview read from a request. etcd limits
+	// objects to 1.5MiB, and a review for an update holds both the new and the old object.
+	maxRequestBodySize = 3 << 20
+)
+
+var (
+	configFileFlag  string
+	tlsCertFileFlag string
+	tlsKeyFileFlag  string
+	addressFlag     string
+	labelPrefixFlag string
+	failOpenFlag    bool
+)
+
+func main() {
+	c := cli.NewApp()
+	c.Name = "nvidia-vgpu-dm-webhook"
+	c.Usage = "Admission webhook rejecting node label changes that select an unknown vGPU config"
+	c.Before = validateFlags
+	c.Action = start
+	c.Version = info.GetVersionString()
+
+	c.Flags = []cli.Flag{
+		&cli.StringFlag{
+			Name:        "config-file",
+			Aliases:     []string{"f"},
+			Value:       "",
+			Usage:       "the path to the vGPU configuration file that selected configs are validated against",
+			Destination: &configFileFlag,
+			EnvVars:     []string{"CONFIG_FILE"},
+		},
+		&cli.StringFlag{
+			Name:        "tls-cert-file",
+			Value:       "",
+			Usage:       "the path to the TLS certificate to serve the webhook with",
+			Destination: &tlsCertFileFlag,
+			EnvVars:     []string{"TLS_CERT_FILE"},
+		},
+		&cli.StringFlag{
+			Name:        "tls-key-file",
+			Value:       "",
+			Usage:       "the path to the private key of the TLS certificate",
+			Destination: &tlsKeyFileFlag,
+			EnvVars:     []string{"TLS_KEY_FILE"},
+		},
+		&cli.StringFlag{
+			Name:        "address",
+			Value:       ":8443",
+			Usage:       "the address to serve the webhook on",
+			Destination: &addressFlag,
+			EnvVars:     []string{"ADDRESS"},
+		},
+		&cli.StringFlag{
+			Name:        "label-prefix",
+			Value:       defaultLabelPrefix,
+			Usage:       "the prefix of the node label used to select the vGPU config (i.e. <label-prefix>.config)",
+			Destination: &labelPrefixFlag,
+			EnvVars:     []string{"LABEL_PREFIX"},
+		},
+		&cli.BoolFlag{
+			Name:        "fail-open",
+			Value:       false,
+			Usage:       "allow label changes when the vGPU config file cannot be loaded, instead of rejecting them",
+			Destination: &failOpenFlag,
+			EnvVars:     []string{"FAIL_OPEN"},
+		},
+	}
+
+	log.Infof("Starting %s version=%s, commit=%s, build-date=%s, go=%s, platform=%s/%s",
+		c.Name, info.GetVersion(), info.GetCommit(), info.GetBuildDate(), runtime.Version(), runtime.GOOS, runtime.GOARCH)
+
+	err := c.Run(os.Args)
+	if err != nil {
+		log.SetOutput(os.Stderr)
+		log.Printf("Error: %v", err)
+		os.Exit(1)
+	}
+}
+
+func validateFlags(c *cli.Context) error {
+	if configFileFlag == "" {
+		return fmt.Errorf("invalid <config-file> flag: must not be empty string")
+	}
+	if tlsCertFileFlag == "" {
+		return fmt.Errorf("invalid <tls-cert-file> flag: must not be empty string")
+	}
+	if tlsKeyFileFlag == "" {
+		return fmt.Errorf("invalid <tls-key-file> flag: must not be empty string")
+	}
+	if labelPrefixFlag == "" {
+		return fmt.Errorf("invalid <label-prefix> flag: must not be empty string")
+	}
+	return nil
+}
+
+func start(c *cli.Context) error {
+	mux := http.NewServeMux()
+	mux.Handle(validatePath, &validator{
+		label:      labelPrefixFlag + ".config",
+		configFile: configFileFlag,
+		failOpen:   failOpenFlag,
+	})
+	mux.HandleFunc(healthzPath, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	log.Infof("Serving admission webhook at https://%s%s", addressFlag, validatePath)
+	err := http.ListenAndServeTLS(addressFlag, tlsCertFileFlag, tlsKeyFileFlag, mux)
+	if err != nil {
+		return fmt.Errorf("error serving admission webhook: %v", err)
+	}
+	return nil
+}
+
+// validator is an 'http.Handler' reviewing Node admission requests. It rejects any
+// change to 'label' whose value is not a named config in 'configFile'. The config
+// file is re-read on every request so that updates to a mounted ConfigMap are honoured.
+// If the config file cannot be loaded, changes to 'label' are rejected unless 'failOpen' is set.
+type validator struct {
+	label      string
+	configFile string
+	failOpen   bool
+}
+
+// ServeHTTP decodes an 'AdmissionReview' request and responds with the review's verdict.
+func (v *validator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestBodySize))
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			http.Error(w, fmt.Sprintf("request body exceeds %d bytes", maxBytesErr.Limit), http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, fmt.Sprintf("unable to read request body: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	var review admissionv1.AdmissionReview
+	err = json.Unmarshal(body, &review)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("unable to decode AdmissionReview: %v", err), http.StatusBadRequest)
+		return
+	}
+	if review.Request == nil {
+		http.Error(w, "AdmissionReview has no request", http.StatusBadRequest)
+		return
+	}
+
+	review.Response = v.review(review.Request)
+	review.Response.UID = review.Request.UID
+	review.Request = nil
+
+	response, err := json.Marshal(&review)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("unable to encode AdmissionReview: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(response)
+}
+
+// review checks a single admission request for a Node.
+func (v *validator) review(req *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+	if req.Kind.Kind != "Node" {
+		return allow()
+	}
+
+	var node, oldNode corev1.Node
+	if err := json.Unmarshal(req.Object.Raw, &node); err != nil {
+		return deny(fmt.Sprintf("unable to decode node: %v", err))
+	}
+	if len(req.OldObject.Raw) > 0 {
+		if err := json.Unmarshal(req.OldObject.Raw, &oldNode); err != nil {
+			return deny(fmt.Sprintf("unable to decode old node: %v", err))
+		}
+	}
+
+	value := node.Labels[v.label]
+	if value == "" || value == oldNode.Labels[v.label] {
+		return allow()
+	}
+
+	s, err := v.loadSpec()
+	if err != nil && v.failOpen {
+		log.Errorf("Unable to load vGPU config file, allowing '%s=%s' on node '%s': %v", v.label, value, node.Name, err)
+		return allow()
+	}
+	if err != nil {
+		log.Errorf("Unable to load vGPU config file: %v", err)
+		return deny(fmt.Sprintf("unable to load vGPU config file to validate '%s': %v", v.label, err))
+	}
+
+	if _, exists := s.GetConfig(value); !exists {
+		log.Infof("Rejecting '%s=%s' on node '%s': no such vGPU config", v.label, value, node.Name)
+		return deny(fmt.Sprintf("invalid value for label '%s': '%s' is not a vGPU config in %s", v.label, value, v.configFile))
+	}
+
+	log.Infof("Allowing '%s=%s' on node '%s'", v.label, value, node.Name)
+	return allow()
+}
+
+// loadSpec parses the vGPU config file, detecting its format from the file extension.
+func (v *validator) loadSpec() (*v1.Spec, error) {
+	data, err := os.ReadFile(v.configFile)
+	if err != nil {
+		return nil, fmt.Errorf("read error: %v", err)
+	}
+	return spec.Parse(data, spec.DetectFormat(v.configFile))
+}
+
+func allow() *admissionv1.AdmissionResponse {
+	return &admissionv1.AdmissionResponse{Allowed: true}
+}
+
+func deny(message string) *admissionv1.AdmissionResponse {
+	return &admissionv1.AdmissionResponse{
+		Allowed: false,
+		Result: &metav1.Status{
+			Status:  metav1.StatusFailure,
+			Message: message,
+			Reason:  metav1.StatusReasonInvalid,
+			Code:    http.StatusUnprocessableEntity,
+		},
+	}
+}
@@ -0,0 +1,242 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+)
+
+const testConfig = `
+version: v1
+vgpu-configs:
+  A100-4C:
+  - devices: all
+    vgpu-devices:
+      "A100-4C": 10
+  A100-5C:
+  - devices: all
+    vgpu-devices:
+      "A100-5C": 8
+`
+
+const testLabel = defaultLabelPrefix + ".config"
+
+func writeTestConfig(t *testing.T) string {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(testConfig), 0644))
+	return path
+}
+
+// newNode returns the JSON encoding of a node with the config label set to 'value', or unset if 'value' is empty.
+func newNode(t *testing.T, value string) []byte {
+	node := corev1.Node{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Node",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "test-node",
+			Labels: map[string]string{},
+		},
+	}
+	if value != "" {
+		node.Labels[testLabel] = value
+	}
+	raw, err := json.Marshal(&node)
+	require.NoError(t, err)
+	return raw
+}
+
+func newReview(kind string, object, oldObject []byte) *admissionv1.AdmissionReview {
+	return &admissionv1.AdmissionReview{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "admission.k8s.io/v1",
+			Kind:       "AdmissionReview",
+		},
+		Request: &admissionv1.AdmissionRequest{
+			UID:       k8stypes.UID("test-uid"),
+			Kind:      metav1.GroupVersionKind{Version: "v1", Kind: kind},
+			Operation: admissionv1.Update,
+			Object:    k8sruntime.RawExtension{Raw: object},
+			OldObject: k8sruntime.RawExtension{Raw: oldObject},
+		},
+	}
+}
+
+func post(t *testing.T, server *httptest.Server, body []byte) *http.Response {
+	resp, err := server.Client().Post(server.URL+validatePath, "application/json", bytes.NewReader(body))
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func TestValidator(t *testing.T) {
+	configFile := writeTestConfig(t)
+	missingConfigFile := filepath.Join(t.TempDir(), "missing.yaml")
+
+	testCases := []struct {
+		description string
+		configFile  string
+		failOpen    bool
+		kind        string
+		oldValue    string
+		newValue    string
+		allowed     bool
+	}{
+		{
+			"Known config",
+			configFile,
+			false,
+			"Node",
+			"A100-4C",
+			"A100-5C",
+			true,
+		},
+		{
+			"Unknown config",
+			configFile,
+			false,
+			"Node",
+			"A100-4C",
+			"A100-40C",
+			false,
+		},
+		{
+			"Unchanged label",
+			missingConfigFile,
+			false,
+			"Node",
+			"A100-40C",
+			"A100-40C",
+			true,
+		},
+		{
+			"Removed label",
+			missingConfigFile,
+			false,
+			"Node",
+			"A100-4C",
+			"",
+			true,
+		},
+		{
+			"Non-Node kind",
+			missingConfigFile,
+			false,
+			"Pod",
+			"",
+			"A100-40C",
+			true,
+		},
+		{
+			"Config file cannot be loaded",
+			missingConfigFile,
+			false,
+			"Node",
+			"A100-4C",
+			"A100-5C",
+			false,
+		},
+		{
+			"Config file cannot be loaded with fail-open",
+			missingConfigFile,
+			true,
+			"Node",
+			"A100-4C",
+			"A100-5C",
+			true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			server := httptest.NewTLSServer(&validator{
+				label:      testLabel,
+				configFile: tc.configFile,
+				failOpen:   tc.failOpen,
+			})
+			defer server.Close()
+
+			body, err := json.Marshal(newReview(tc.kind, newNode(t, tc.newValue), newNode(t, tc.oldValue)))
+			require.NoError(t, err)
+
+			resp := post(t, server, body)
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+
+			var review admissionv1.AdmissionReview
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&review))
+			require.Nil(t, review.Request)
+			require.NotNil(t, review.Response)
+			require.Equal(t, k8stypes.UID("test-uid"), review.Response.UID)
+			require.Equal(t, tc.allowed, review.Response.Allowed)
+			if !tc.allowed {
+				require.Equal(t, int32(http.StatusUnprocessableEntity), review.Response.Result.Code)
+			}
+		})
+	}
+}
+
+func TestValidatorMalformedRequest(t *testing.T) {
+	server := httptest.NewTLSServer(&validator{
+		label:      testLabel,
+		configFile: writeTestConfig(t),
+	})
+	defer server.Close()
+
+	testCases := []struct {
+		description string
+		body        []byte
+		status      int
+	}{
+		{
+			"Malformed body",
+			[]byte("{not json"),
+			http.StatusBadRequest,
+		},
+		{
+			"Review without a request",
+			[]byte(`{"apiVersion": "admission.k8s.io/v1", "kind": "AdmissionReview"}`),
+			http.StatusBadRequest,
+		},
+		{
+			"Body too large",
+			[]byte(`{"padding": "` + strings.Repeat("a", maxRequestBodySize) + `"}`),
+			http.StatusRequestEntityTooLarge,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			resp := post(t, server, tc.body)
+			require.Equal(t, tc.status, resp.StatusCode)
+		})
+	}
+}
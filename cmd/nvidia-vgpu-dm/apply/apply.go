@@ -18,6 +18,7 @@ package apply
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	cli "github.com/urfave/cli/v2"
@@ -35,12 +36,22 @@ func GetLogger() *logrus.Logger {
 // Flags for the 'apply' command
 type Flags struct {
 	assert.Flags
+	ConfigPreview  bool
+	Verify         bool
+	VerifyTimeout  time.Duration
+	VerifyInterval time.Duration
+	ReportFile     string
+	PartialApply   bool
+	Atomic         bool
+	DryRun         bool
+	Force          bool
 }
 
 // Context containing CLI flags and the selected VGPUConfig to apply
 type Context struct {
 	assert.Context
-	Flags *Flags
+	Flags  *Flags
+	Report *Report
 }
 
 // BuildCommand builds the 'apply' command
@@ -55,28 +66,99 @@ func BuildCommand() *cli.Command {
 	}
 
 	apply.Flags = []cli.Flag{
-		&cli.StringFlag{
-			Name:        "config-file",
-			Aliases:     []string{"f"},
-			Usage:       "Path to the configuration file",
-			Destination: &applyFlags.ConfigFile,
-			EnvVars:     []string{"VGPU_DM_CONFIG_FILE"},
+		&cli.BoolFlag{
+			Name:        "strict-device-filter",
+			Usage:       "Require every device filter in the config file to be a valid PCI device ID",
+			Destination: &applyFlags.StrictDeviceFilter,
+			EnvVars:     []string{"VGPU_DM_STRICT_DEVICE_FILTER"},
+		},
+		&cli.BoolFlag{
+			Name:        "config-preview",
+			Usage:       "Print a table of the changes that would be made to each GPU without applying them",
+			Destination: &applyFlags.ConfigPreview,
+			EnvVars:     []string{"VGPU_DM_CONFIG_PREVIEW"},
+		},
+		&cli.BoolFlag{
+			Name:        "verify",
+			Usage:       "Assert that the vGPU device configuration is applied after applying it",
+			Destination: &applyFlags.Verify,
+			EnvVars:     []string{"VGPU_DM_VERIFY"},
+		},
+		&cli.DurationFlag{
+			Name:        "verify-timeout",
+			Value:       30 * time.Second,
+			Usage:       "The maximum time to wait for the applied vGPU device configuration to be verified",
+			Destination: &applyFlags.VerifyTimeout,
+			EnvVars:     []string{"VGPU_DM_VERIFY_TIMEOUT"},
+		},
+		&cli.DurationFlag{
+			Name:        "verify-interval",
+			Value:       2 * time.Second,
+			Usage:       "The time to wait between attempts to verify the applied vGPU device configuration",
+			Destination: &applyFlags.VerifyInterval,
+			EnvVars:     []string{"VGPU_DM_VERIFY_INTERVAL"},
 		},
 		&cli.StringFlag{
-			Name:        "selected-config",
-			Aliases:     []string{"c"},
-			Usage:       "The label of the vgpu-config from the config file to apply to the node",
-			Destination: &applyFlags.SelectedConfig,
-			EnvVars:     []string{"VGPU_DM_SELECTED_CONFIG"},
+			Name:        "report-file",
+			Usage:       "Path to write a JSON report of the apply results to ('-' for stdout)",
+			Destination: &applyFlags.ReportFile,
+			EnvVars:     []string{"VGPU_DM_REPORT_FILE"},
+		},
+		&cli.BoolFlag{
+			Name:        "partial-apply",
+			Usage:       "Skip GPUs that the vGPU device configuration cannot be applied to instead of failing",
+			Destination: &applyFlags.PartialApply,
+			EnvVars:     []string{"VGPU_DM_PARTIAL_APPLY"},
+		},
+		&cli.BoolFlag{
+			Name:        "atomic",
+			Aliases:     []string{"rollback-on-failure"},
+			Usage:       "Roll back all GPUs to their previous vGPU device configuration if the configuration cannot be applied to every GPU ('--rollback-on-failure' is the same flag under another name)",
+			Destination: &applyFlags.Atomic,
+			EnvVars:     []string{"VGPU_DM_ATOMIC"},
+		},
+		&cli.BoolFlag{
+			Name:        "dry-run",
+			Usage:       "Log the vGPU devices that would be deleted and created on each GPU without applying them (exits with 2 if anything would change)",
+			Destination: &applyFlags.DryRun,
+			EnvVars:     []string{"VGPU_DM_DRY_RUN"},
+		},
+		&cli.BoolFlag{
+			Name:        "force",
+			Usage:       "Keep retrying to delete existing vGPU devices that are in use (every 5s, for up to 2m) instead of failing immediately",
+			Destination: &applyFlags.Force,
+			EnvVars:     []string{"VGPU_DM_FORCE"},
 		},
 	}
 
+	// Also accept the top-level config flags after the subcommand name.
+	apply.Flags = append(apply.Flags, assert.SelectedConfigFlags()...)
+
 	return &apply
 }
 
 // CheckFlags ensures that any required flags are provided and ensures they are well-formed.
-func CheckFlags(f *Flags) error {
-	return assert.CheckFlags(&f.Flags)
+func CheckFlags(c *cli.Context, f *Flags) error {
+	err := assert.CheckFlags(c, &f.Flags)
+	if err != nil {
+		return err
+	}
+	if f.Verify && f.VerifyTimeout <= 0 {
+		return fmt.Errorf("invalid value for 'verify-timeout': %v", f.VerifyTimeout)
+	}
+	if f.Verify && f.VerifyInterval <= 0 {
+		return fmt.Errorf("invalid value for 'verify-interval': %v", f.VerifyInterval)
+	}
+	if f.Verify && f.PartialApply {
+		return fmt.Errorf("'verify' cannot be combined with 'partial-apply'")
+	}
+	if f.Atomic && f.PartialApply {
+		return fmt.Errorf("'atomic' cannot be combined with 'partial-apply'")
+	}
+	if f.DryRun && (f.Verify || f.PartialApply) {
+		return fmt.Errorf("'dry-run' cannot be combined with 'verify' or 'partial-apply'")
+	}
+	return nil
 }
 
 // AssertVGPUConfig reuses calls from the 'assert' subcommand to check if the vGPU devices of a particular vGPU config are currently applied.
@@ -91,8 +173,25 @@ func (c *Context) ApplyVGPUConfig() error {
 	return VGPUConfig(c)
 }
 
+// VerifyVGPUConfig repeatedly asserts that the vGPU devices of a particular vGPU config are applied,
+// until the assertion succeeds or the verify timeout expires.
+func (c *Context) VerifyVGPUConfig() error {
+	deadline := time.Now().Add(c.Flags.VerifyTimeout)
+	for {
+		err := c.AssertVGPUConfig()
+		if err == nil {
+			return nil
+		}
+		if time.Now().Add(c.Flags.VerifyInterval).After(deadline) {
+			return fmt.Errorf("verification failed despite successful apply: %v", err)
+		}
+		log.Debugf("Verification not yet successful, retrying in %v: %v", c.Flags.VerifyInterval, err)
+		time.Sleep(c.Flags.VerifyInterval)
+	}
+}
+
 func applyWrapper(c *cli.Context, f *Flags) error {
-	err := CheckFlags(f)
+	err := CheckFlags(c, f)
 	if err != nil {
 		_ = cli.ShowSubcommandHelp(c)
 		return err
@@ -105,13 +204,13 @@ func applyWrapper(c *cli.Context, f *Flags) error {
 	}
 
 	log.Debugf("Selecting specific vGPU config...")
-	vgpuConfig, err := assert.GetSelectedVGPUConfig(&f.Flags, spec)
+	vgpuConfig, selectedConfig, err := assert.GetSelectedVGPUConfig(&f.Flags, spec)
 	if err != nil {
 		return fmt.Errorf("error selecting VGPU config: %v", err)
 	}
 
 	if f.ValidConfig {
-		log.Infof("Selected vGPU device configuration is valid")
+		log.WithField("config_name", selectedConfig).Infof("Selected vGPU device configuration is valid")
 		return nil
 	}
 
@@ -124,16 +223,61 @@ func applyWrapper(c *cli.Context, f *Flags) error {
 		},
 	}
 
+	if f.ConfigPreview {
+		log.Debugf("Previewing vGPU device configuration...")
+		return ConfigPreview(&context)
+	}
+
+	if f.DryRun {
+		log.Debugf("Dry-running vGPU device configuration...")
+		return DryRun(&context)
+	}
+
+	if f.ReportFile != "" {
+		context.Report = NewReport(selectedConfig)
+	}
+
+	err = applyAndVerify(&context)
+	if context.Report != nil {
+		context.Report.Finish(err)
+		reportErr := context.Report.WriteFile(f.ReportFile)
+		if reportErr != nil {
+			if err == nil {
+				return fmt.Errorf("error writing report file: %v", reportErr)
+			}
+			log.Errorf("Error writing report file: %v", reportErr)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	log.WithField("config_name", selectedConfig).Infof("Selected vGPU device configuration successfully applied")
+	return nil
+}
+
+func applyAndVerify(c *Context) error {
 	log.Debugf("Checking current vGPU device configuration...")
-	err = context.AssertVGPUConfig()
+	err := c.AssertVGPUConfig()
+	// When reporting, always walk the GPUs so that each one is recorded in
+	// the report. GPUs that are already configured are skipped by the walk.
+	if err == nil && c.Report == nil {
+		return nil
+	}
+
+	log.Infof("Applying vGPU device configuration...")
+	err = c.ApplyVGPUConfig()
 	if err != nil {
-		log.Infof("Applying vGPU device configuration...")
-		err := context.ApplyVGPUConfig()
+		return err
+	}
+
+	if c.Flags.Verify {
+		log.Infof("Verifying vGPU device configuration...")
+		err := c.VerifyVGPUConfig()
 		if err != nil {
 			return err
 		}
 	}
 
-	log.Infof("Selected vGPU device configuration successfully applied")
 	return nil
 }
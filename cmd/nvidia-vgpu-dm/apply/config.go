@@ -17,7 +17,11 @@
 package apply
 
 import (
+	"errors"
 	"fmt"
+	"time"
+
+	cli "github.com/urfave/cli/v2"
 
 	v1 "github.com/NVIDIA/vgpu-device-manager/api/spec/v1"
 	"github.com/NVIDIA/vgpu-device-manager/cmd/nvidia-vgpu-dm/assert"
@@ -25,26 +29,111 @@ import (
 	"github.com/NVIDIA/vgpu-device-manager/pkg/vgpu"
 )
 
-// VGPUConfig applies the selected vGPU config to the node
+// exitCodeAllGPUsSkipped is returned when a partial apply skips every GPU it visits
+const exitCodeAllGPUsSkipped = 2
+
+const (
+	// forceRetryInterval is the time to wait between attempts to apply a config when 'force' is set
+	forceRetryInterval = 5 * time.Second
+	// forceRetryTimeout is the maximum time to keep retrying to apply a config when 'force' is set
+	forceRetryTimeout = 2 * time.Minute
+)
+
+// VGPUConfig applies the selected vGPU config to the node.
+// With a partial apply, GPUs the config cannot be applied to are skipped rather than failing the apply.
+// With an atomic apply, a failure on any GPU rolls back all GPUs visited so far to their previous config.
 func VGPUConfig(c *Context) error {
-	return assert.WalkSelectedVGPUConfigForEachGPU(c.VGPUConfig, func(vc *v1.VGPUConfigSpec, i int, d types.DeviceID) error {
-		configManager := vgpu.NewNvlibVGPUConfigManager()
-		current, err := configManager.GetVGPUConfig(i)
-		if err != nil {
-			return fmt.Errorf("error getting vGPU config: %v", err)
+	configured, skipped := 0, 0
+	snapshot := vgpu.NewSnapshot()
+	err := assert.WalkSelectedVGPUConfigForEachGPU(c.VGPUConfig, func(vc *v1.VGPUConfigSpec, i int, d types.DeviceID) error {
+		current, err := applyVGPUConfigToGPU(vc, i, c.Flags.Force)
+		if current != nil {
+			snapshot.Add(i, current)
 		}
-
-		if current.Equals(vc.VGPUDevices) {
-			log.Debugf("    Skipping -- already set to desired value")
+		c.Report.AddGPUResult(i, current, vc.VGPUDevices, err)
+		if err != nil && c.Flags.PartialApply {
+			log.Warnf("    Skipping GPU %d: %v", i, err)
+			skipped++
 			return nil
 		}
+		if err == nil {
+			configured++
+		}
+		return err
+	})
+	if err != nil && c.Flags.Atomic {
+		return rollbackVGPUConfigs(vgpu.NewNvlibVGPUConfigManager(), snapshot, err)
+	}
+	if err != nil {
+		return err
+	}
 
-		log.Debugf("    Updating vGPU config: %v", vc.VGPUDevices)
-		err = configManager.SetVGPUConfig(i, vc.VGPUDevices)
-		if err != nil {
-			return fmt.Errorf("error setting VGPU config: %v", err)
+	if c.Flags.PartialApply {
+		log.Infof("Configured %d GPU(s), skipped %d GPU(s)", configured, skipped)
+		if configured == 0 && skipped > 0 {
+			return cli.Exit("vGPU device configuration could not be applied to any GPU", exitCodeAllGPUsSkipped)
 		}
+	}
 
-		return nil
-	})
+	return nil
+}
+
+// applyVGPUConfigToGPU applies a 'VGPUConfigSpec' to the GPU at index 'i' and returns the config it replaced
+func applyVGPUConfigToGPU(vc *v1.VGPUConfigSpec, i int, force bool) (types.VGPUConfig, error) {
+	configManager := vgpu.NewNvlibVGPUConfigManager()
+	current, err := configManager.GetVGPUConfig(i)
+	if err != nil {
+		return nil, fmt.Errorf("error getting vGPU config: %v", err)
+	}
+
+	desired, err := configManager.ResolveVGPUConfig(i, vc.VGPUDevices)
+	if err != nil {
+		return current, fmt.Errorf("error resolving vGPU config: %v", err)
+	}
+
+	if current.Equals(desired) {
+		log.Debugf("    Skipping -- already set to desired value")
+		return current, nil
+	}
+
+	log.Debugf("    Updating vGPU config: %v", desired)
+	err = setVGPUConfig(configManager, i, desired, force)
+	if err != nil {
+		return current, fmt.Errorf("error setting VGPU config: %v", err)
+	}
+
+	return current, nil
+}
+
+// setVGPUConfig applies 'config' to the GPU at index 'i'. With 'force', an apply that fails because some of the
+// existing vGPU devices could not be deleted is retried every 'forceRetryInterval' for up to 'forceRetryTimeout'.
+func setVGPUConfig(configManager vgpu.Manager, i int, config types.VGPUConfig, force bool) error {
+	deadline := time.Now().Add(forceRetryTimeout)
+	for {
+		err := configManager.SetVGPUConfig(i, config)
+		var deletionErr *vgpu.DeviceDeletionError
+		if err == nil || !force || !errors.As(err, &deletionErr) {
+			return err
+		}
+		if time.Now().Add(forceRetryInterval).After(deadline) {
+			return fmt.Errorf("giving up after %v: %w", forceRetryTimeout, err)
+		}
+		log.Warnf("    %d vGPU device(s) on GPU %d could not be deleted, retrying in %v", len(deletionErr.Errors), i, forceRetryInterval)
+		log.Debugf("    %v", err)
+		time.Sleep(forceRetryInterval)
+	}
+}
+
+// rollbackVGPUConfigs restores each GPU in 'snapshot' to the config it had before the apply.
+// The returned error wraps 'cause' and lists the GPUs that were rolled back. If a GPU could not
+// be rolled back, the returned '*vgpu.RollbackError' says so explicitly, since the node then
+// needs manual attention.
+func rollbackVGPUConfigs(configManager vgpu.Manager, snapshot *vgpu.Snapshot, cause error) error {
+	log.Warnf("Applying vGPU device configuration failed, rolling back: %v", cause)
+	rolledBack, err := snapshot.Restore(configManager, cause)
+	if err != nil {
+		log.Errorf("Rollback incomplete, the vGPU devices on the node must be checked and reconfigured manually")
+		return err
+	}
+	return fmt.Errorf("%w; rolled back GPUs %v", cause, rolledBack)
 }
@@ -0,0 +1,92 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package apply
+
+import (
+	"fmt"
+	"sort"
+
+	cli "github.com/urfave/cli/v2"
+
+	v1 "github.com/NVIDIA/vgpu-device-manager/api/spec/v1"
+	"github.com/NVIDIA/vgpu-device-manager/cmd/nvidia-vgpu-dm/assert"
+	"github.com/NVIDIA/vgpu-device-manager/pkg/types"
+	"github.com/NVIDIA/vgpu-device-manager/pkg/vgpu"
+)
+
+// exitCodeChangesPending is returned by a dry run when applying the config would change at least one GPU
+const exitCodeChangesPending = 2
+
+// DryRun logs the vGPU devices that applying the selected vGPU config would delete and create on each GPU.
+// No changes are made to the node. An error is returned if the config cannot be applied to any of the GPUs,
+// and an exit code of 'exitCodeChangesPending' if any changes would be made.
+func DryRun(c *Context) error {
+	configManager := vgpu.NewNvlibVGPUConfigManager()
+
+	changed := false
+	err := assert.WalkSelectedVGPUConfigForEachGPU(c.VGPUConfig, func(vc *v1.VGPUConfigSpec, i int, d types.DeviceID) error {
+		current, err := configManager.GetVGPUConfig(i)
+		if err != nil {
+			return fmt.Errorf("error getting vGPU config: %v", err)
+		}
+
+		desired, err := configManager.ResolveVGPUConfig(i, vc.VGPUDevices)
+		if err != nil {
+			return fmt.Errorf("error resolving vGPU config: %v", err)
+		}
+
+		if current.Equals(desired) {
+			log.Infof("no changes on GPU %d", i)
+			return nil
+		}
+
+		err = configManager.AssertVGPUConfigSupported(i, vc.VGPUDevices)
+		if err != nil {
+			return fmt.Errorf("GPU %d: %v", i, err)
+		}
+
+		// Applying a config always clears all existing vGPU devices on the
+		// GPU before creating the desired ones.
+		for _, vgpuType := range sortedVGPUTypes(current) {
+			log.Infof("would delete %d x %s on GPU %d", current[vgpuType], vgpuType, i)
+		}
+		for _, vgpuType := range sortedVGPUTypes(desired) {
+			log.Infof("would create %d x %s on GPU %d", desired[vgpuType], vgpuType, i)
+		}
+		changed = true
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if changed {
+		return cli.Exit("Selected vGPU device configuration would change the node", exitCodeChangesPending)
+	}
+
+	log.Infof("Selected vGPU device configuration is already applied")
+	return nil
+}
+
+func sortedVGPUTypes(config types.VGPUConfig) []string {
+	var vgpuTypes []string
+	for vgpuType := range config {
+		vgpuTypes = append(vgpuTypes, vgpuType)
+	}
+	sort.Strings(vgpuTypes)
+	return vgpuTypes
+}
@@ -0,0 +1,107 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package apply
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/NVIDIA/go-nvlib/pkg/nvpci"
+
+	v1 "github.com/NVIDIA/vgpu-device-manager/api/spec/v1"
+	"github.com/NVIDIA/vgpu-device-manager/cmd/nvidia-vgpu-dm/assert"
+	"github.com/NVIDIA/vgpu-device-manager/pkg/types"
+	"github.com/NVIDIA/vgpu-device-manager/pkg/vgpu"
+)
+
+const (
+	actionSkip  = "SKIP"
+	actionApply = "APPLY"
+	actionError = "ERROR"
+)
+
+// ConfigPreview prints a table of the changes that applying the selected vGPU config would make to each GPU.
+// No changes are made to the node. An error is returned if the config cannot be applied to any of the GPUs.
+func ConfigPreview(c *Context) error {
+	pci := nvpci.New()
+	configManager := vgpu.NewNvlibVGPUConfigManager()
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "GPU\tPCI Address\tCurrent Config\tDesired Config\tAction")
+
+	failed := false
+	err := assert.WalkSelectedVGPUConfigForEachGPU(c.VGPUConfig, func(vc *v1.VGPUConfigSpec, i int, d types.DeviceID) error {
+		gpu, err := pci.GetGPUByIndex(i)
+		if err != nil {
+			return fmt.Errorf("error getting device at index '%d': %v", i, err)
+		}
+
+		current, err := configManager.GetVGPUConfig(i)
+		if err != nil {
+			return fmt.Errorf("error getting vGPU config: %v", err)
+		}
+
+		desired, err := configManager.ResolveVGPUConfig(i, vc.VGPUDevices)
+		if err != nil {
+			return fmt.Errorf("error resolving vGPU config: %v", err)
+		}
+
+		action := actionApply
+		if current.Equals(desired) {
+			action = actionSkip
+		} else if err := configManager.AssertVGPUConfigSupported(i, vc.VGPUDevices); err != nil {
+			log.Warnf("GPU %d: %v", i, err)
+			action = actionError
+			failed = true
+		}
+
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", i, gpu.Address, formatVGPUConfig(current), formatVGPUConfig(desired), action)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	err = w.Flush()
+	if err != nil {
+		return fmt.Errorf("error writing config preview: %v", err)
+	}
+
+	if failed {
+		return fmt.Errorf("selected vGPU config cannot be applied to all GPUs")
+	}
+
+	return nil
+}
+
+// formatVGPUConfig renders a 'VGPUConfig' as a sorted, comma-separated list of <type>=<count> pairs
+func formatVGPUConfig(config types.VGPUConfig) string {
+	if len(config) == 0 {
+		return "-"
+	}
+
+	var entries []string
+	for vgpuType, count := range config {
+		entries = append(entries, fmt.Sprintf("%s=%d", vgpuType, count))
+	}
+	sort.Strings(entries)
+
+	return strings.Join(entries, ",")
+}
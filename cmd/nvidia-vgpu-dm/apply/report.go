@@ -0,0 +1,118 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package apply
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/NVIDIA/go-nvlib/pkg/nvpci"
+
+	"github.com/NVIDIA/vgpu-device-manager/pkg/types"
+)
+
+// ReportVersion indicates the version of the 'Report' struct written by the 'apply' command.
+const ReportVersion = "v1"
+
+// Report holds the results of applying a vGPU config to the node, for use in audit logs.
+type Report struct {
+	ReportVersion   string      `json:"report_version"`
+	Timestamp       time.Time   `json:"timestamp"`
+	NodeName        string      `json:"node_name,omitempty"`
+	SelectedConfig  string      `json:"selected_config"`
+	GPUs            []GPUResult `json:"gpus"`
+	Success         bool        `json:"success"`
+	Error           string      `json:"error,omitempty"`
+	DurationSeconds float64     `json:"duration_seconds"`
+}
+
+// GPUResult holds the result of applying a vGPU config to a single GPU.
+type GPUResult struct {
+	Index          int              `json:"index"`
+	Address        string           `json:"address"`
+	PreviousConfig types.VGPUConfig `json:"previous_config"`
+	NewConfig      types.VGPUConfig `json:"new_config,omitempty"`
+	Success        bool             `json:"success"`
+	Error          string           `json:"error,omitempty"`
+}
+
+// NewReport creates a new 'Report' for applying the selected vGPU config.
+func NewReport(selectedConfig string) *Report {
+	return &Report{
+		ReportVersion:  ReportVersion,
+		Timestamp:      time.Now().UTC(),
+		NodeName:       os.Getenv("NODE_NAME"),
+		SelectedConfig: selectedConfig,
+		GPUs:           []GPUResult{},
+	}
+}
+
+// AddGPUResult records the result of applying 'desired' to the GPU at index 'gpu'.
+// It is a no-op on a nil 'Report' so that callers need not check whether reporting is enabled.
+func (r *Report) AddGPUResult(gpu int, previous, desired types.VGPUConfig, err error) {
+	if r == nil {
+		return
+	}
+
+	result := GPUResult{
+		Index:          gpu,
+		PreviousConfig: previous,
+		Success:        err == nil,
+	}
+	if device, err := nvpci.New().GetGPUByIndex(gpu); err == nil {
+		result.Address = device.Address
+	}
+	if err != nil {
+		result.Error = err.Error()
+	} else {
+		result.NewConfig = desired
+	}
+
+	r.GPUs = append(r.GPUs, result)
+}
+
+// Finish records the overall result of the apply and its total duration.
+func (r *Report) Finish(err error) {
+	r.Success = err == nil
+	if err != nil {
+		r.Error = err.Error()
+	}
+	r.DurationSeconds = time.Since(r.Timestamp).Seconds()
+}
+
+// WriteFile writes the 'Report' as JSON to 'path', or to stdout if 'path' is '-'.
+func (r *Report) WriteFile(path string) error {
+	output, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal error: %v", err)
+	}
+	output = append(output, '\n')
+
+	if path == "-" {
+		_, err = os.Stdout.Write(output)
+		return err
+	}
+
+	err = os.WriteFile(path, output, 0644)
+	if err != nil {
+		return fmt.Errorf("write error: %v", err)
+	}
+
+	return nil
+}
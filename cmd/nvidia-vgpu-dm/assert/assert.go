@@ -20,7 +20,10 @@ import (
 	"bufio"
 	"fmt"
 	"os"
+	"slices"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/NVIDIA/go-nvlib/pkg/nvpci"
 	"github.com/sirupsen/logrus"
@@ -28,6 +31,7 @@ import (
 	"sigs.k8s.io/yaml"
 
 	v1 "github.com/NVIDIA/vgpu-device-manager/api/spec/v1"
+	"github.com/NVIDIA/vgpu-device-manager/pkg/spec"
 	"github.com/NVIDIA/vgpu-device-manager/pkg/types"
 )
 
@@ -40,9 +44,16 @@ func GetLogger() *logrus.Logger {
 
 // Flags for the 'assert' command
 type Flags struct {
-	ConfigFile     string
-	SelectedConfig string
-	ValidConfig    bool
+	ConfigFile         string
+	ConfigFormat       string
+	SelectedConfig     string
+	ValidConfig        bool
+	StrictDeviceFilter bool
+	ValidationMode     string
+	ExpectedConfig     string
+	Watch              bool
+	WatchInterval      time.Duration
+	WatchFailFast      bool
 }
 
 // Context containing CLI flags and the selected VGPUConfig to assert
@@ -64,20 +75,6 @@ func BuildCommand() *cli.Command {
 	}
 
 	assert.Flags = []cli.Flag{
-		&cli.StringFlag{
-			Name:        "config-file",
-			Aliases:     []string{"f"},
-			Usage:       "Path to the configuration file",
-			Destination: &assertFlags.ConfigFile,
-			EnvVars:     []string{"VGPU_DM_CONFIG_FILE"},
-		},
-		&cli.StringFlag{
-			Name:        "selected-config",
-			Aliases:     []string{"c"},
-			Usage:       "The name of the vgpu-config from the config file to assert is applied to the node",
-			Destination: &assertFlags.SelectedConfig,
-			EnvVars:     []string{"VGPU_DM_SELECTED_CONFIG"},
-		},
 		&cli.BoolFlag{
 			Name:        "valid-config",
 			Aliases:     []string{"a"},
@@ -85,32 +82,82 @@ func BuildCommand() *cli.Command {
 			Destination: &assertFlags.ValidConfig,
 			EnvVars:     []string{"VGPU_DM_VALID_CONFIG"},
 		},
+		&cli.BoolFlag{
+			Name:        "strict-device-filter",
+			Usage:       "Require every device filter in the config file to be a valid PCI device ID",
+			Destination: &assertFlags.StrictDeviceFilter,
+			EnvVars:     []string{"VGPU_DM_STRICT_DEVICE_FILTER"},
+		},
+		&cli.StringFlag{
+			Name:        "config-validation-mode",
+			Usage:       "How to treat unrecognised vGPU types in the config file: 'strict' fails, 'permissive' skips them with a warning",
+			Value:       string(spec.ValidationModeStrict),
+			Destination: &assertFlags.ValidationMode,
+			EnvVars:     []string{"VGPU_DM_CONFIG_VALIDATION_MODE"},
+		},
+		&cli.StringFlag{
+			Name:        "expected-config",
+			Usage:       "An inline vGPU config (as YAML or JSON, e.g. '{\"A100-4C\": 3}') to assert is applied to all GPUs, instead of a config file",
+			Destination: &assertFlags.ExpectedConfig,
+			EnvVars:     []string{"VGPU_DM_EXPECTED_CONFIG"},
+		},
+		&cli.BoolFlag{
+			Name:        "watch",
+			Usage:       "Continuously re-assert the selected config every 'watch-interval' until interrupted",
+			Destination: &assertFlags.Watch,
+			EnvVars:     []string{"VGPU_DM_WATCH"},
+		},
+		&cli.DurationFlag{
+			Name:        "watch-interval",
+			Usage:       "The interval between assertions when 'watch' is set",
+			Value:       30 * time.Second,
+			Destination: &assertFlags.WatchInterval,
+			EnvVars:     []string{"VGPU_DM_WATCH_INTERVAL"},
+		},
+		&cli.BoolFlag{
+			Name:        "watch-fail-fast",
+			Usage:       "Exit with status 1 on the first failed assertion when 'watch' is set",
+			Destination: &assertFlags.WatchFailFast,
+			EnvVars:     []string{"VGPU_DM_WATCH_FAIL_FAST"},
+		},
 	}
 
+	// Also accept the top-level config flags after the subcommand name.
+	assert.Flags = append(assert.Flags, SelectedConfigFlags()...)
+
 	return &assert
 }
 
 func assertWrapper(c *cli.Context, f *Flags) error {
-	err := CheckFlags(f)
+	err := CheckFlags(c, f)
 	if err != nil {
 		_ = cli.ShowSubcommandHelp(c)
 		return err
 	}
 
-	log.Debugf("Parsing config file...")
-	spec, err := ParseConfigFile(f)
-	if err != nil {
-		return fmt.Errorf("error parsing config file: %v", err)
+	var spec *v1.Spec
+	if f.ExpectedConfig != "" {
+		log.Debugf("Parsing expected config...")
+		spec, err = ParseExpectedConfig(f.ExpectedConfig)
+		if err != nil {
+			return fmt.Errorf("error parsing expected config: %v", err)
+		}
+	} else {
+		log.Debugf("Parsing config file...")
+		spec, err = ParseConfigFile(f)
+		if err != nil {
+			return fmt.Errorf("error parsing config file: %v", err)
+		}
 	}
 
 	log.Debugf("Selecting specific vGPU config...")
-	vgpuConfig, err := GetSelectedVGPUConfig(f, spec)
+	vgpuConfig, selectedConfig, err := GetSelectedVGPUConfig(f, spec)
 	if err != nil {
 		return fmt.Errorf("error selecting VGPU config: %v", err)
 	}
 
 	if f.ValidConfig {
-		log.Infof("Selected vGPU device configuration is valid")
+		log.WithField("config_name", selectedConfig).Infof("Selected vGPU device configuration is valid")
 		return nil
 	}
 
@@ -120,6 +167,11 @@ func assertWrapper(c *cli.Context, f *Flags) error {
 		VGPUConfig: vgpuConfig,
 	}
 
+	if f.Watch {
+		log.Debugf("Watching vGPU device configuration every %v...", f.WatchInterval)
+		return watchVGPUConfig(&context)
+	}
+
 	log.Debugf("Asserting vGPU device configuration...")
 	err = VGPUConfig(&context)
 	if err != nil {
@@ -127,12 +179,47 @@ func assertWrapper(c *cli.Context, f *Flags) error {
 		return fmt.Errorf("Assertion failure: selected configuration not currently applied")
 	}
 
-	log.Infof("Selected vGPU device configuration is currently applied")
+	log.WithField("config_name", selectedConfig).Infof("Selected vGPU device configuration is currently applied")
 	return nil
 }
 
 // CheckFlags ensures that any required flags are provided and ensures they are well-formed.
-func CheckFlags(f *Flags) error {
+// The 'config-file', 'config-format' and 'selected-config' flags are shared by all subcommands
+// and are read from whichever command in the context lineage of 'c' they were set on.
+func CheckFlags(c *cli.Context, f *Flags) error {
+	f.ConfigFile = LookupString(c, "config-file")
+	f.ConfigFormat = LookupString(c, "config-format")
+	f.SelectedConfig = LookupString(c, "selected-config")
+
+	if f.ConfigFormat != "" && !spec.IsSupportedFormat(f.ConfigFormat) {
+		return fmt.Errorf("invalid value for 'config-format': %v", f.ConfigFormat)
+	}
+
+	if f.ValidationMode != "" && !spec.IsValidValidationMode(f.ValidationMode) {
+		return fmt.Errorf("invalid value for 'config-validation-mode': %v", f.ValidationMode)
+	}
+
+	if f.Watch {
+		if f.ValidConfig {
+			return fmt.Errorf("flags 'watch' and 'valid-config' are mutually exclusive")
+		}
+		if f.WatchInterval <= 0 {
+			return fmt.Errorf("flag 'watch-interval' must be greater than 0")
+		}
+	} else if f.WatchFailFast {
+		return fmt.Errorf("flag 'watch-fail-fast' requires 'watch'")
+	}
+
+	if f.ExpectedConfig != "" {
+		if f.ConfigFile != "" {
+			return fmt.Errorf("flags 'expected-config' and 'config-file' are mutually exclusive")
+		}
+		if f.SelectedConfig != "" {
+			return fmt.Errorf("flag 'selected-config' cannot be used with 'expected-config'")
+		}
+		return nil
+	}
+
 	var missing []string
 	if f.ConfigFile == "" {
 		missing = append(missing, "config-file")
@@ -143,9 +230,38 @@ func CheckFlags(f *Flags) error {
 	return nil
 }
 
-// ParseConfigFile parses the vGPU device configuration file
-func ParseConfigFile(f *Flags) (*v1.Spec, error) {
-	var err error
+// ParseExpectedConfig builds an in-memory 'Spec' from an inline 'VGPUConfig'.
+// The resulting 'Spec' contains a single config that applies to all devices.
+func ParseExpectedConfig(expected string) (*v1.Spec, error) {
+	var config types.VGPUConfig
+	err := yaml.Unmarshal([]byte(expected), &config)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshal error: %v", err)
+	}
+
+	err = config.AssertValid()
+	if err != nil {
+		return nil, fmt.Errorf("invalid vGPU config: %v", err)
+	}
+
+	spec := &v1.Spec{
+		Version: v1.Version,
+		VGPUConfigs: map[string]v1.VGPUConfigSpecSlice{
+			"expected-config": {
+				{
+					Devices:     "all",
+					VGPUDevices: config,
+				},
+			},
+		},
+	}
+
+	return spec, nil
+}
+
+// ReadConfigFile reads the raw contents of the vGPU device configuration file.
+// A 'ConfigFile' of '-' is read from stdin.
+func ReadConfigFile(f *Flags) ([]byte, error) {
 	var configYaml []byte
 
 	if f.ConfigFile == "-" {
@@ -154,76 +270,171 @@ func ParseConfigFile(f *Flags) (*v1.Spec, error) {
 			configYaml = append(configYaml, scanner.Bytes()...)
 			configYaml = append(configYaml, '\n')
 		}
-	} else {
-		configYaml, err = os.ReadFile(f.ConfigFile)
-		if err != nil {
-			return nil, fmt.Errorf("read error: %v", err)
-		}
+		return configYaml, nil
 	}
 
-	var spec v1.Spec
-	err = yaml.Unmarshal(configYaml, &spec)
+	configYaml, err := os.ReadFile(f.ConfigFile)
 	if err != nil {
-		return nil, fmt.Errorf("unmarshal error: %v", err)
+		return nil, fmt.Errorf("read error: %v", err)
 	}
-
-	return &spec, nil
+	return configYaml, nil
 }
 
-// GetSelectedVGPUConfig gets the selected VGPUConfigSpecSlice from the config file
-func GetSelectedVGPUConfig(f *Flags, spec *v1.Spec) (v1.VGPUConfigSpecSlice, error) {
-	if len(spec.VGPUConfigs) > 1 && f.SelectedConfig == "" {
-		return nil, fmt.Errorf("missing required flag 'selected-config' when more than one config available")
+// ParseConfigFile parses the vGPU device configuration file.
+// Unless 'ConfigFormat' is set, the format of the file is detected from its extension.
+func ParseConfigFile(f *Flags) (*v1.Spec, error) {
+	configYaml, err := ReadConfigFile(f)
+	if err != nil {
+		return nil, err
+	}
+
+	format := f.ConfigFormat
+	if format == "" {
+		format = spec.DetectFormat(f.ConfigFile)
 	}
 
-	if len(spec.VGPUConfigs) == 1 && f.SelectedConfig == "" {
-		for c := range spec.VGPUConfigs {
-			f.SelectedConfig = c
+	s, err := spec.Parse(configYaml, format, spec.WithValidationMode(spec.ValidationMode(f.ValidationMode)))
+	if err != nil {
+		return nil, err
+	}
+
+	// Report every remaining problem in the config file, each qualified by its path.
+	if errs := spec.Validate(s); len(errs) > 0 {
+		var messages []string
+		for _, e := range errs {
+			messages = append(messages, e.Error())
 		}
+		return nil, fmt.Errorf("invalid config file: %s", strings.Join(messages, "; "))
 	}
 
-	if _, exists := spec.VGPUConfigs[f.SelectedConfig]; !exists {
-		return nil, fmt.Errorf("selected vgpu-config not present: %v", f.SelectedConfig)
+	if f.StrictDeviceFilter {
+		err = s.AssertValidDeviceFilters()
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	return spec.VGPUConfigs[f.SelectedConfig], nil
+	return s, nil
 }
 
-// WalkSelectedVGPUConfigForEachGPU applies a function 'f' to the selected 'VGPUConfig' for each GPU on the node
+// GetSelectedVGPUConfig gets the selected VGPUConfigSpecSlice from the config file along with its name.
+// If no config is selected and the config file contains only one config, that config is selected.
+func GetSelectedVGPUConfig(f *Flags, spec *v1.Spec) (v1.VGPUConfigSpecSlice, string, error) {
+	selectedConfig := f.SelectedConfig
+	if len(spec.VGPUConfigs) > 1 && selectedConfig == "" {
+		return nil, "", fmt.Errorf("missing required flag 'selected-config' when more than one config available")
+	}
+
+	if len(spec.VGPUConfigs) == 1 && selectedConfig == "" {
+		selectedConfig = spec.ConfigNames()[0]
+	}
+
+	config, exists := spec.GetConfig(selectedConfig)
+	if !exists {
+		return nil, "", fmt.Errorf("selected vgpu-config not present: %v", selectedConfig)
+	}
+
+	return config, selectedConfig, nil
+}
+
+// WalkSelectedVGPUConfigForEachGPU applies a function 'f' to the selected 'VGPUConfig' for each GPU on the node.
+// GPUs are walked in groups of the same device ID, and for each group only the entries whose device filter matches
+// it are walked. Entries are walked in order of descending priority, and only visit the GPUs their 'devices' field
+// selects. A GPU matched by an entry is skipped by all entries of a lower priority, while entries of the same
+// priority all apply to it in the order they appear in.
 func WalkSelectedVGPUConfigForEachGPU(vgpuConfig v1.VGPUConfigSpecSlice, f func(*v1.VGPUConfigSpec, int, types.DeviceID) error) error {
 	nvpci := nvpci.New()
 	gpus, err := nvpci.GetGPUs()
 	if err != nil {
 		return fmt.Errorf("error enumerating GPUs: %v", err)
 	}
+	return walkVGPUConfigForEachGPU(gpus, vgpuConfig, f)
+}
 
-	for _, vc := range vgpuConfig {
-		if vc.DeviceFilter == nil {
-			log.Debugf("Walking VGPUConfig for (devices=%v)", vc.Devices)
-		} else {
-			log.Debugf("Walking VGPUConfig for (device-filter=%v, devices=%v)", vc.DeviceFilter, vc.Devices)
+// walkVGPUConfigForEachGPU applies a function 'f' to the selected 'VGPUConfig' for each GPU in 'gpus', which are
+// indexed by their position.
+func walkVGPUConfigForEachGPU(gpus []*nvpci.NvidiaPCIDevice, vgpuConfig v1.VGPUConfigSpecSlice, f func(*v1.VGPUConfigSpec, int, types.DeviceID) error) error {
+	// The device ID of each GPU, and the distinct device IDs on the node in order of the first GPU with each
+	gpuDeviceIDs := make([]types.DeviceID, len(gpus))
+	var deviceIDs []types.DeviceID
+	for i := range gpus {
+		gpuDeviceIDs[i] = types.NewDeviceID(gpus[i].Device, gpus[i].Vendor)
+		if !slices.Contains(deviceIDs, gpuDeviceIDs[i]) {
+			deviceIDs = append(deviceIDs, gpuDeviceIDs[i])
 		}
+	}
 
-		for i, gpu := range gpus {
-			deviceID := types.NewDeviceID(gpu.Device, gpu.Vendor)
-
-			if !vc.MatchesDeviceFilter(deviceID) {
-				continue
+	sorted := vgpuConfig.SortedByPriority()
+	for _, deviceID := range deviceIDs {
+		// The priority of the entry each GPU was first matched by
+		matched := make(map[int]int)
+		for _, vc := range sorted.FilterByDeviceID(deviceID) {
+			switch {
+			case vc.DeviceFilter == nil && vc.ModelFilter == nil:
+				log.Debugf("Walking VGPUConfig for (devices=%v)", vc.Devices)
+			case vc.ModelFilter == nil:
+				log.Debugf("Walking VGPUConfig for (device-filter=%v, devices=%v)", vc.DeviceFilter, vc.Devices)
+			default:
+				log.Debugf("Walking VGPUConfig for (device-filter=%v, model-filter=%v, devices=%v)", vc.DeviceFilter, vc.ModelFilter, vc.Devices)
 			}
 
-			if !vc.MatchesDevices(i) {
-				continue
+			for _, i := range selectedGPUIndices(&vc, len(gpus)) {
+				if gpuDeviceIDs[i] != deviceID {
+					continue
+				}
+
+				// The model name is taken from the PCI IDs database (i.e. "GA100 [A100 PCIe 40GB]").
+				if vc.ModelFilter != nil && !vc.MatchesGPUModel(gpus[i].DeviceName) {
+					continue
+				}
+
+				if priority, exists := matched[i]; exists && priority > vc.Priority {
+					log.Debugf("  GPU %v: skipped -- already matched by an entry of priority %v", i, priority)
+					continue
+				}
+				if _, exists := matched[i]; !exists {
+					matched[i] = vc.Priority
+				}
+
+				log.Debugf("  GPU %v: %v", i, deviceID)
+
+				// nolint: gosec
+				err := f(&vc, i, deviceID)
+				if err != nil {
+					return err
+				}
 			}
+		}
+	}
 
-			log.Debugf("  GPU %v: %v", i, deviceID)
+	return nil
+}
 
-			// nolint: gosec
-			err = f(&vc, i, deviceID)
-			if err != nil {
-				return err
-			}
+// selectedGPUIndices returns the sorted indices of the GPUs selected by the 'devices' field of a 'VGPUConfigSpec'.
+// Indices outside the range of GPUs on the node are ignored.
+func selectedGPUIndices(vc *v1.VGPUConfigSpec, numGPUs int) []int {
+	var indices []int
+	if vc.MatchesAllDevices() {
+		for i := 0; i < numGPUs; i++ {
+			indices = append(indices, i)
 		}
+		return indices
 	}
 
-	return nil
+	devices, ok := vc.Devices.([]int)
+	if !ok {
+		return nil
+	}
+
+	seen := make(map[int]bool)
+	for _, d := range devices {
+		if d < 0 || d >= numGPUs || seen[d] {
+			continue
+		}
+		seen[d] = true
+		indices = append(indices, d)
+	}
+	sort.Ints(indices)
+
+	return indices
 }
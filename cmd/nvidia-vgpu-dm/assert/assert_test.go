@@ -0,0 +1,116 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package assert
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/NVIDIA/go-nvlib/pkg/nvpci"
+	"github.com/stretchr/testify/require"
+
+	v1 "github.com/NVIDIA/vgpu-device-manager/api/spec/v1"
+	"github.com/NVIDIA/vgpu-device-manager/pkg/types"
+)
+
+const (
+	a100DeviceID = "0x20B010DE"
+	t4DeviceID   = "0x1EB810DE"
+)
+
+func TestWalkVGPUConfigForEachGPU(t *testing.T) {
+	a100 := &nvpci.NvidiaPCIDevice{Device: 0x20b0, Vendor: 0x10de, DeviceName: "GA100 [A100 PCIe 40GB]"}
+	t4 := &nvpci.NvidiaPCIDevice{Device: 0x1eb8, Vendor: 0x10de, DeviceName: "TU104GL [Tesla T4]"}
+	gpus := []*nvpci.NvidiaPCIDevice{a100, t4, a100, t4}
+
+	testCases := []struct {
+		description string
+		vgpuConfig  v1.VGPUConfigSpecSlice
+		// The GPUs visited by the walk, as "<entry>:<GPU index>"
+		visited []string
+	}{
+		{
+			"All devices",
+			v1.VGPUConfigSpecSlice{
+				{Devices: "all", VGPUDevices: types.VGPUConfig{"A100-4C": 10}},
+			},
+			[]string{"0:0", "0:2", "0:1", "0:3"},
+		},
+		{
+			"Specific devices",
+			v1.VGPUConfigSpecSlice{
+				{Devices: []int{3, 0, 3, 7}, VGPUDevices: types.VGPUConfig{"A100-4C": 10}},
+			},
+			[]string{"0:0", "0:3"},
+		},
+		{
+			"Device filter",
+			v1.VGPUConfigSpecSlice{
+				{Devices: "all", DeviceFilter: t4DeviceID, VGPUDevices: types.VGPUConfig{"T4-1Q": 16}},
+				{Devices: "all", DeviceFilter: a100DeviceID, VGPUDevices: types.VGPUConfig{"A100-4C": 10}},
+			},
+			[]string{"1:0", "1:2", "0:1", "0:3"},
+		},
+		{
+			"Device filter and specific devices",
+			v1.VGPUConfigSpecSlice{
+				{Devices: []int{0, 1}, DeviceFilter: []string{t4DeviceID}, VGPUDevices: types.VGPUConfig{"T4-1Q": 16}},
+			},
+			[]string{"0:1"},
+		},
+		{
+			"Model filter",
+			v1.VGPUConfigSpecSlice{
+				{Devices: "all", ModelFilter: "T4", VGPUDevices: types.VGPUConfig{"T4-1Q": 16}},
+			},
+			[]string{"0:1", "0:3"},
+		},
+		{
+			"Higher priority entries win",
+			v1.VGPUConfigSpecSlice{
+				{Devices: "all", VGPUDevices: types.VGPUConfig{"A100-4C": 10}},
+				{Devices: []int{2}, Priority: 1, VGPUDevices: types.VGPUConfig{"A100-5C": 8}},
+			},
+			[]string{"1:2", "0:0", "0:1", "0:3"},
+		},
+		{
+			"Entries of the same priority all apply",
+			v1.VGPUConfigSpecSlice{
+				{Devices: []int{0}, VGPUDevices: types.VGPUConfig{"A100-4C": 5}},
+				{Devices: []int{0}, VGPUDevices: types.VGPUConfig{"A100-5C": 4}},
+			},
+			[]string{"0:0", "1:0"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			var visited []string
+			err := walkVGPUConfigForEachGPU(gpus, tc.vgpuConfig, func(vc *v1.VGPUConfigSpec, i int, d types.DeviceID) error {
+				for j := range tc.vgpuConfig {
+					if vc.VGPUDevices.Equals(tc.vgpuConfig[j].VGPUDevices) {
+						visited = append(visited, fmt.Sprintf("%d:%d", j, i))
+					}
+				}
+				require.Equal(t, types.NewDeviceID(gpus[i].Device, gpus[i].Vendor), d)
+				return nil
+			})
+			require.NoError(t, err)
+			require.Equal(t, tc.visited, visited)
+		})
+	}
+}
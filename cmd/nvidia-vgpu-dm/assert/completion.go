@@ -0,0 +1,62 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package assert
+
+import (
+	"fmt"
+	"os"
+
+	cli "github.com/urfave/cli/v2"
+)
+
+// CompleteSelectedConfig is a shell completion function that completes the
+// value of the '--selected-config' flag with the config names found in the file
+// passed via '--config-file'. All other completions fall back to the default
+// flag and subcommand suggestions.
+func CompleteSelectedConfig(c *cli.Context) {
+	// The word being completed is stripped by the completion script, so the
+	// word before '--generate-bash-completion' is the flag being completed.
+	var lastArg string
+	if len(os.Args) > 2 {
+		lastArg = os.Args[len(os.Args)-2]
+	}
+
+	switch lastArg {
+	case "-c", "--selected-config":
+	default:
+		cli.DefaultAppComplete(c)
+		return
+	}
+
+	// Without a config file (or when reading it from stdin) there is
+	// nothing we can complete from.
+	f := &Flags{
+		ConfigFile: LookupString(c, "config-file"),
+	}
+	if f.ConfigFile == "" || f.ConfigFile == "-" {
+		return
+	}
+
+	spec, err := ParseConfigFile(f)
+	if err != nil {
+		return
+	}
+
+	for _, name := range spec.ConfigNames() {
+		fmt.Fprintln(c.App.Writer, name)
+	}
+}
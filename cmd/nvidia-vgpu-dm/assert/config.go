@@ -19,8 +19,6 @@ package assert
 import (
 	"fmt"
 
-	"github.com/NVIDIA/go-nvlib/pkg/nvpci"
-
 	v1 "github.com/NVIDIA/vgpu-device-manager/api/spec/v1"
 	"github.com/NVIDIA/vgpu-device-manager/pkg/types"
 	"github.com/NVIDIA/vgpu-device-manager/pkg/vgpu"
@@ -28,22 +26,28 @@ import (
 
 // VGPUConfig asserts that the selected vGPU config is applied to the node
 func VGPUConfig(c *Context) error {
-	nvpci := nvpci.New()
-	gpus, err := nvpci.GetGPUs()
-	if err != nil {
-		return fmt.Errorf("error enumerating GPUs: %v", err)
-	}
+	return assertVGPUConfig(c, vgpu.NewNvlibVGPUConfigManager())
+}
 
-	matched := make([]bool, len(gpus))
-	err = WalkSelectedVGPUConfigForEachGPU(c.VGPUConfig, func(vc *v1.VGPUConfigSpec, i int, d types.DeviceID) error {
-		configManager := vgpu.NewNvlibVGPUConfigManager()
+// assertVGPUConfig asserts that the selected vGPU config is applied to the node, as reported by 'configManager'
+func assertVGPUConfig(c *Context, configManager vgpu.Manager) error {
+	// Only GPUs visited by the walk are covered by the selected config. GPUs
+	// that no entry applies to are left out of 'matched' and never fail the
+	// assertion.
+	matched := make(map[int]bool)
+	err := WalkSelectedVGPUConfigForEachGPU(c.VGPUConfig, func(vc *v1.VGPUConfigSpec, i int, d types.DeviceID) error {
 		current, err := configManager.GetVGPUConfig(i)
 		if err != nil {
 			return fmt.Errorf("error getting vGPU config: %v", err)
 		}
 
-		log.Debugf("    Asserting vGPU config: %v", vc.VGPUDevices)
-		if current.Equals(vc.VGPUDevices) {
+		desired, err := configManager.ResolveVGPUConfig(i, vc.VGPUDevices)
+		if err != nil {
+			return fmt.Errorf("error resolving vGPU config: %v", err)
+		}
+
+		log.Debugf("    Asserting vGPU config: %v", desired)
+		if current.Equals(desired) {
 			log.Debugf("    Skipping -- already set to desired value")
 			matched[i] = true
 			return nil
@@ -0,0 +1,64 @@
+/*
+ * Copyright (c) 2022, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package assert
+
+import (
+	"github.com/urfave/cli/v2"
+)
+
+// ConfigFileFlags returns hidden copies of the top-level 'config-file' and 'config-format' flags.
+// Adding them to a subcommand keeps invocations that pass them after the subcommand name
+// (e.g. 'nvidia-vgpu-dm apply -f config.yaml') working. Use LookupString to read them.
+func ConfigFileFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:    "config-file",
+			Aliases: []string{"f"},
+			Usage:   "Path to the configuration file",
+			Hidden:  true,
+		},
+		&cli.StringFlag{
+			Name:   "config-format",
+			Usage:  "The format of the configuration file, one of 'yaml', 'json' or 'toml' (detected from the file extension if unset)",
+			Hidden: true,
+		},
+	}
+}
+
+// SelectedConfigFlags returns hidden copies of the top-level 'config-file', 'config-format'
+// and 'selected-config' flags, for subcommands that operate on a selected config.
+func SelectedConfigFlags() []cli.Flag {
+	return append(ConfigFileFlags(), &cli.StringFlag{
+		Name:    "selected-config",
+		Aliases: []string{"c"},
+		Usage:   "The name of the vgpu-config from the config file to apply or assert",
+		Hidden:  true,
+	})
+}
+
+// LookupString returns the value of the string flag 'name' from the innermost command in the
+// context lineage of 'c' it was set on, so that a flag passed after a subcommand name takes
+// precedence over the same flag passed (or set through its environment variable) on the
+// top-level command. An empty string is returned if it was not set on any of them.
+func LookupString(c *cli.Context, name string) string {
+	for _, ctx := range c.Lineage() {
+		if ctx.IsSet(name) {
+			return ctx.String(name)
+		}
+	}
+	return ""
+}
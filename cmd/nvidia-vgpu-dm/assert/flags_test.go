@@ -0,0 +1,106 @@
+/*
+ * Copyright (c) 2022, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package assert
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v2"
+)
+
+func TestLookupString(t *testing.T) {
+	testCases := []struct {
+		description    string
+		args           []string
+		env            string
+		expectedFile   string
+		expectedConfig string
+	}{
+		{
+			"Flags before the subcommand",
+			[]string{"-f", "top.yaml", "-c", "top", "sub"},
+			"",
+			"top.yaml",
+			"top",
+		},
+		{
+			"Flags after the subcommand",
+			[]string{"sub", "-f", "sub.yaml", "--selected-config", "sub"},
+			"",
+			"sub.yaml",
+			"sub",
+		},
+		{
+			"Flags after the subcommand take precedence",
+			[]string{"-f", "top.yaml", "-c", "top", "sub", "-c", "sub"},
+			"",
+			"top.yaml",
+			"sub",
+		},
+		{
+			"Flags after the subcommand take precedence over the environment",
+			[]string{"sub", "--config-file", "sub.yaml"},
+			"env.yaml",
+			"sub.yaml",
+			"",
+		},
+		{
+			"Environment of the top-level flag",
+			[]string{"sub"},
+			"env.yaml",
+			"env.yaml",
+			"",
+		},
+		{
+			"Unset",
+			[]string{"sub"},
+			"",
+			"",
+			"",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			t.Setenv("TEST_CONFIG_FILE", tc.env)
+
+			var file, config string
+			app := cli.NewApp()
+			app.Flags = []cli.Flag{
+				&cli.StringFlag{Name: "config-file", Aliases: []string{"f"}, EnvVars: []string{"TEST_CONFIG_FILE"}},
+				&cli.StringFlag{Name: "selected-config", Aliases: []string{"c"}},
+			}
+			app.Commands = []*cli.Command{
+				{
+					Name:  "sub",
+					Flags: SelectedConfigFlags(),
+					Action: func(c *cli.Context) error {
+						file = LookupString(c, "config-file")
+						config = LookupString(c, "selected-config")
+						return nil
+					},
+				},
+			}
+
+			err := app.Run(append([]string{"nvidia-vgpu-dm"}, tc.args...))
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedFile, file)
+			require.Equal(t, tc.expectedConfig, config)
+		})
+	}
+}
@@ -0,0 +1,80 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package assert
+
+import (
+	"context"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	cli "github.com/urfave/cli/v2"
+
+	"github.com/NVIDIA/vgpu-device-manager/pkg/vgpu"
+)
+
+// watchVGPUConfig repeatedly asserts the selected vGPU config every 'WatchInterval'
+// until the command is interrupted. A failed assertion is logged and the loop continues,
+// unless 'WatchFailFast' is set, in which case the command exits with status 1.
+func watchVGPUConfig(c *Context) error {
+	ctx, stop := signal.NotifyContext(c.Context.Context, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	ticker := time.NewTicker(c.Flags.WatchInterval)
+	defer ticker.Stop()
+
+	assert := func(m vgpu.Manager) error {
+		return assertVGPUConfig(c, m)
+	}
+	return watchLoop(ctx, vgpu.NewNvlibVGPUConfigManager(), ticker.C, c.Flags.WatchFailFast, assert)
+}
+
+// watchLoop runs 'assert' against 'manager' once immediately and then on every tick of 'ticks', until 'ctx'
+// is done. If 'failFast' is set, the first failed assertion ends the loop with an exit status of 1.
+func watchLoop(ctx context.Context, manager vgpu.Manager, ticks <-chan time.Time, failFast bool, assert func(vgpu.Manager) error) error {
+	for {
+		err := assertOnce(manager, assert)
+		if err != nil && failFast {
+			return cli.Exit("Assertion failure: selected configuration not currently applied", 1)
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Debugf("Stopping watch: %v", context.Cause(ctx))
+			return nil
+		case <-ticks:
+		}
+	}
+}
+
+// assertOnce runs a single assertion of the selected vGPU config and logs its result with a timestamp
+func assertOnce(manager vgpu.Manager, assert func(vgpu.Manager) error) error {
+	entry := log.WithFields(logrus.Fields{
+		"timestamp": time.Now().Format(time.RFC3339),
+	})
+
+	err := assert(manager)
+	if err != nil {
+		entry.Debug(err.Error())
+		entry.Warnf("Selected vGPU device configuration is not currently applied")
+		return err
+	}
+
+	entry.Infof("Selected vGPU device configuration is currently applied")
+	return nil
+}
@@ -0,0 +1,126 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package assert
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	cli "github.com/urfave/cli/v2"
+
+	"github.com/NVIDIA/vgpu-device-manager/pkg/types"
+	"github.com/NVIDIA/vgpu-device-manager/pkg/vgpu"
+)
+
+// fakeManager is a 'vgpu.Manager' reporting a scripted sequence of configs for GPU 0.
+// Once the sequence is exhausted, its last config keeps being reported.
+type fakeManager struct {
+	vgpu.Manager
+	configs []types.VGPUConfig
+	calls   int
+}
+
+func (m *fakeManager) GetVGPUConfig(gpu int) (types.VGPUConfig, error) {
+	i := m.calls
+	if i >= len(m.configs) {
+		i = len(m.configs) - 1
+	}
+	m.calls++
+	return m.configs[i], nil
+}
+
+func TestWatchLoop(t *testing.T) {
+	desired := types.VGPUConfig{"A100-4C": 2}
+	drifted := types.VGPUConfig{"A100-4C": 1}
+
+	testCases := []struct {
+		description   string
+		configs       []types.VGPUConfig
+		failFast      bool
+		assertions    int
+		expectedCalls int
+		expectedExit  bool
+	}{
+		{
+			"Drift is only logged without fail-fast",
+			[]types.VGPUConfig{desired, drifted, drifted, desired},
+			false,
+			4,
+			4,
+			false,
+		},
+		{
+			"Drift detected after a successful assertion exits with fail-fast",
+			[]types.VGPUConfig{desired, desired, drifted, desired},
+			true,
+			4,
+			3,
+			true,
+		},
+		{
+			"First failed assertion exits with fail-fast",
+			[]types.VGPUConfig{drifted, desired},
+			true,
+			4,
+			1,
+			true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			// One assertion runs before the first tick, so queue one tick fewer than the
+			// number of assertions and stop the loop once the last one has run.
+			ticks := make(chan time.Time, tc.assertions-1)
+			for i := 0; i < tc.assertions-1; i++ {
+				ticks <- time.Now()
+			}
+
+			manager := &fakeManager{configs: tc.configs}
+			assert := func(m vgpu.Manager) error {
+				current, err := m.GetVGPUConfig(0)
+				if manager.calls == tc.assertions {
+					cancel()
+				}
+				if err != nil {
+					return err
+				}
+				if !current.Equals(desired) {
+					return fmt.Errorf("not all GPUs match the specified config")
+				}
+				return nil
+			}
+
+			err := watchLoop(ctx, manager, ticks, tc.failFast, assert)
+			require.Equal(t, tc.expectedCalls, manager.calls)
+			if !tc.expectedExit {
+				require.NoError(t, err)
+				return
+			}
+
+			var exitErr cli.ExitCoder
+			require.ErrorAs(t, err, &exitErr)
+			require.Equal(t, 1, exitErr.ExitCode())
+		})
+	}
+}
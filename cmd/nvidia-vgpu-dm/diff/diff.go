@@ -0,0 +1,163 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package diff
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/NVIDIA/go-nvlib/pkg/nvpci"
+	"github.com/sirupsen/logrus"
+	cli "github.com/urfave/cli/v2"
+
+	v1 "github.com/NVIDIA/vgpu-device-manager/api/spec/v1"
+	"github.com/NVIDIA/vgpu-device-manager/cmd/nvidia-vgpu-dm/assert"
+	"github.com/NVIDIA/vgpu-device-manager/pkg/types"
+	"github.com/NVIDIA/vgpu-device-manager/pkg/vgpu"
+)
+
+// Exit codes of the 'diff' command, matching the semantics of diff(1)
+const (
+	exitCodeDiff  = 1
+	exitCodeError = 2
+)
+
+var log = logrus.New()
+
+// GetLogger returns the logger for the 'diff' command
+func GetLogger() *logrus.Logger {
+	return log
+}
+
+// BuildCommand builds the 'diff' command
+func BuildCommand() *cli.Command {
+	diffFlags := assert.Flags{}
+
+	diff := cli.Command{}
+	diff.Name = "diff"
+	diff.Usage = "Show the difference between the vGPU devices currently on the node and a specific vGPU device configuration"
+	diff.Action = func(c *cli.Context) error {
+		err := diffWrapper(c, &diffFlags)
+		if err != nil {
+			if _, ok := err.(cli.ExitCoder); ok {
+				return err
+			}
+			return cli.Exit(err.Error(), exitCodeError)
+		}
+		return nil
+	}
+
+	// Also accept the top-level config flags after the subcommand name.
+	diff.Flags = assert.SelectedConfigFlags()
+
+	return &diff
+}
+
+func diffWrapper(c *cli.Context, f *assert.Flags) error {
+	err := assert.CheckFlags(c, f)
+	if err != nil {
+		_ = cli.ShowSubcommandHelp(c)
+		return err
+	}
+
+	log.Debugf("Parsing config file...")
+	spec, err := assert.ParseConfigFile(f)
+	if err != nil {
+		return fmt.Errorf("error parsing config file: %v", err)
+	}
+
+	log.Debugf("Selecting specific vGPU config...")
+	vgpuConfig, _, err := assert.GetSelectedVGPUConfig(f, spec)
+	if err != nil {
+		return fmt.Errorf("error selecting VGPU config: %v", err)
+	}
+
+	changed, err := VGPUConfig(vgpuConfig)
+	if err != nil {
+		return err
+	}
+	if changed {
+		return cli.Exit("", exitCodeDiff)
+	}
+
+	return nil
+}
+
+// VGPUConfig prints the vGPU types that would be removed from ('-'), added to ('+') or have their count
+// changed ('~') on each GPU to apply 'vgpuConfig', along with the number of vGPU devices left unchanged.
+// It returns true if any GPU differs from 'vgpuConfig'.
+func VGPUConfig(vgpuConfig v1.VGPUConfigSpecSlice) (bool, error) {
+	nvpci := nvpci.New()
+	configManager := vgpu.NewNvlibVGPUConfigManager()
+
+	changed := false
+	err := assert.WalkSelectedVGPUConfigForEachGPU(vgpuConfig, func(vc *v1.VGPUConfigSpec, i int, d types.DeviceID) error {
+		gpu, err := nvpci.GetGPUByIndex(i)
+		if err != nil {
+			return fmt.Errorf("error getting device at index '%d': %v", i, err)
+		}
+
+		current, err := configManager.GetVGPUConfig(i)
+		if err != nil {
+			return fmt.Errorf("error getting vGPU config: %v", err)
+		}
+
+		desired, err := configManager.ResolveVGPUConfig(i, vc.VGPUDevices)
+		if err != nil {
+			return fmt.Errorf("error resolving vGPU config: %v", err)
+		}
+
+		diff := current.Diff(desired)
+		if !diff.IsEmpty() {
+			changed = true
+		}
+
+		unchanged := 0
+		for vgpuType, count := range desired {
+			if count > 0 && current[vgpuType] == count {
+				unchanged += count
+			}
+		}
+
+		fmt.Printf("GPU %d (%s):\n", i, gpu.Address)
+		for _, vgpuType := range sortedVGPUTypes(diff.Removed) {
+			fmt.Printf("- %s x%d\n", vgpuType, diff.Removed[vgpuType])
+		}
+		for _, vgpuType := range sortedVGPUTypes(diff.Added) {
+			fmt.Printf("+ %s x%d\n", vgpuType, diff.Added[vgpuType])
+		}
+		for _, vgpuType := range sortedVGPUTypes(diff.Changed) {
+			fmt.Printf("~ %s x%d -> x%d\n", vgpuType, current[vgpuType], diff.Changed[vgpuType])
+		}
+		fmt.Printf("  %d unchanged\n", unchanged)
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return changed, nil
+}
+
+func sortedVGPUTypes(config map[string]int) []string {
+	var vgpuTypes []string
+	for vgpuType := range config {
+		vgpuTypes = append(vgpuTypes, vgpuType)
+	}
+	sort.Strings(vgpuTypes)
+	return vgpuTypes
+}
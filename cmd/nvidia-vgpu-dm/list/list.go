@@ -0,0 +1,138 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package list
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/sirupsen/logrus"
+	cli "github.com/urfave/cli/v2"
+
+	"github.com/NVIDIA/vgpu-device-manager/cmd/nvidia-vgpu-dm/assert"
+	"github.com/NVIDIA/vgpu-device-manager/pkg/spec"
+)
+
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+)
+
+var log = logrus.New()
+
+// GetLogger returns the logger for the 'list' command
+func GetLogger() *logrus.Logger {
+	return log
+}
+
+// Flags for the 'list' command
+type Flags struct {
+	ConfigFile   string
+	ConfigFormat string
+	Output       string
+}
+
+// BuildCommand builds the 'list' command
+func BuildCommand() *cli.Command {
+	listFlags := Flags{}
+
+	list := cli.Command{}
+	list.Name = "list"
+	list.Usage = "List the vGPU device configurations defined in a configuration file"
+	list.Action = func(c *cli.Context) error {
+		return listWrapper(c, &listFlags)
+	}
+
+	list.Flags = []cli.Flag{
+		&cli.StringFlag{
+			Name:        "output",
+			Aliases:     []string{"o"},
+			Value:       outputTable,
+			Usage:       "The output format, one of 'table' or 'json'",
+			Destination: &listFlags.Output,
+			EnvVars:     []string{"VGPU_DM_OUTPUT"},
+		},
+	}
+
+	// Also accept the top-level config flags after the subcommand name.
+	list.Flags = append(list.Flags, assert.ConfigFileFlags()...)
+
+	return &list
+}
+
+// CheckFlags ensures that any required flags are provided and ensures they are well-formed.
+// The 'config-file' and 'config-format' flags are read from whichever command in the context lineage of 'c' they were set on.
+func CheckFlags(c *cli.Context, f *Flags) error {
+	f.ConfigFile = assert.LookupString(c, "config-file")
+	f.ConfigFormat = assert.LookupString(c, "config-format")
+	if f.ConfigFile == "" {
+		return fmt.Errorf("missing required flags 'config-file'")
+	}
+	if f.ConfigFormat != "" && !spec.IsSupportedFormat(f.ConfigFormat) {
+		return fmt.Errorf("invalid value for 'config-format': %v", f.ConfigFormat)
+	}
+	switch f.Output {
+	case outputTable, outputJSON:
+	default:
+		return fmt.Errorf("invalid value for 'output': %v", f.Output)
+	}
+	return nil
+}
+
+func listWrapper(c *cli.Context, f *Flags) error {
+	err := CheckFlags(c, f)
+	if err != nil {
+		_ = cli.ShowSubcommandHelp(c)
+		return err
+	}
+
+	log.Debugf("Parsing config file...")
+	s, err := assert.ParseConfigFile(&assert.Flags{ConfigFile: f.ConfigFile, ConfigFormat: f.ConfigFormat})
+	if err != nil {
+		return fmt.Errorf("error parsing config file: %v", err)
+	}
+
+	summaries := spec.Summarize(s)
+	if f.Output == outputJSON {
+		output, err := json.MarshalIndent(summaries, "", "  ")
+		if err != nil {
+			return fmt.Errorf("error marshaling config list: %v", err)
+		}
+		fmt.Println(string(output))
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Name\tEntries\tMIG-Backed\tDevice Filters")
+	for _, summary := range summaries {
+		filters := "-"
+		if len(summary.DeviceFilters) > 0 {
+			filters = strings.Join(summary.DeviceFilters, ",")
+		}
+		fmt.Fprintf(w, "%s\t%d\t%t\t%s\n", summary.Name, summary.Entries, summary.MIGBacked, filters)
+	}
+
+	err = w.Flush()
+	if err != nil {
+		return fmt.Errorf("error writing config list: %v", err)
+	}
+
+	return nil
+}
@@ -17,6 +17,7 @@
 package main
 
 import (
+	"fmt"
 	"os"
 
 	log "github.com/sirupsen/logrus"
@@ -24,12 +25,23 @@ import (
 
 	"github.com/NVIDIA/vgpu-device-manager/cmd/nvidia-vgpu-dm/apply"
 	"github.com/NVIDIA/vgpu-device-manager/cmd/nvidia-vgpu-dm/assert"
+	"github.com/NVIDIA/vgpu-device-manager/cmd/nvidia-vgpu-dm/diff"
+	"github.com/NVIDIA/vgpu-device-manager/cmd/nvidia-vgpu-dm/list"
+	"github.com/NVIDIA/vgpu-device-manager/cmd/nvidia-vgpu-dm/migrate"
+	"github.com/NVIDIA/vgpu-device-manager/cmd/nvidia-vgpu-dm/status"
 	"github.com/NVIDIA/vgpu-device-manager/internal/info"
 )
 
+const (
+	logFormatText = "text"
+	logFormatJSON = "json"
+)
+
 // Flags represents the top level flags that can be passed to the vgpu-dm CLI
 type Flags struct {
-	Debug bool
+	Debug     bool
+	LogLevel  string
+	LogFormat string
 }
 
 func main() {
@@ -39,31 +51,86 @@ func main() {
 	c.Name = "nvidia-vgpu-dm"
 	c.Usage = "Manage NVIDIA vGPU devices"
 	c.Version = info.GetVersionString()
+	c.EnableBashCompletion = true
+	c.BashComplete = assert.CompleteSelectedConfig
 
 	c.Flags = []cli.Flag{
 		&cli.BoolFlag{
 			Name:        "debug",
 			Aliases:     []string{"d"},
-			Usage:       "Enable debug-level logging",
+			Usage:       "Enable debug-level logging (deprecated: use '--log-level=debug')",
 			Destination: &flags.Debug,
 			EnvVars:     []string{"VGPU_DM_DEBUG"},
 		},
+		&cli.StringFlag{
+			Name:        "log-level",
+			Value:       log.InfoLevel.String(),
+			Usage:       "The log level, one of 'panic', 'fatal', 'error', 'warning', 'info', 'debug' or 'trace'",
+			Destination: &flags.LogLevel,
+			EnvVars:     []string{"VGPU_DM_LOG_LEVEL"},
+		},
+		&cli.StringFlag{
+			Name:        "log-format",
+			Value:       logFormatText,
+			Usage:       "The log format, one of 'text' or 'json'",
+			Destination: &flags.LogFormat,
+			EnvVars:     []string{"VGPU_DM_LOG_FORMAT"},
+		},
+		&cli.StringFlag{
+			Name:    "config-file",
+			Aliases: []string{"f"},
+			Usage:   "Path to the configuration file",
+			EnvVars: []string{"VGPU_DM_CONFIG_FILE"},
+		},
+		&cli.StringFlag{
+			Name:    "config-format",
+			Usage:   "The format of the configuration file, one of 'yaml', 'json' or 'toml' (detected from the file extension if unset)",
+			EnvVars: []string{"VGPU_DM_CONFIG_FORMAT"},
+		},
+		&cli.StringFlag{
+			Name:    "selected-config",
+			Aliases: []string{"c"},
+			Usage:   "The name of the vgpu-config from the config file to apply or assert",
+			EnvVars: []string{"VGPU_DM_SELECTED_CONFIG"},
+		},
 	}
 
 	c.Commands = []*cli.Command{
 		apply.BuildCommand(),
 		assert.BuildCommand(),
+		list.BuildCommand(),
+		diff.BuildCommand(),
+		migrate.BuildCommand(),
+		status.BuildCommand(),
 	}
 
 	c.Before = func(c *cli.Context) error {
-		logLevel := log.InfoLevel
+		logLevel, err := log.ParseLevel(flags.LogLevel)
+		if err != nil {
+			return fmt.Errorf("invalid value for 'log-level': %v", flags.LogLevel)
+		}
 		if flags.Debug {
 			logLevel = log.DebugLevel
 		}
-		assertLog := assert.GetLogger()
-		assertLog.SetLevel(logLevel)
-		applyLog := apply.GetLogger()
-		applyLog.SetLevel(logLevel)
+		if flags.LogFormat != logFormatText && flags.LogFormat != logFormatJSON {
+			return fmt.Errorf("invalid value for 'log-format': %v", flags.LogFormat)
+		}
+
+		loggers := []*log.Logger{
+			log.StandardLogger(),
+			assert.GetLogger(),
+			apply.GetLogger(),
+			list.GetLogger(),
+			diff.GetLogger(),
+			migrate.GetLogger(),
+			status.GetLogger(),
+		}
+		for _, logger := range loggers {
+			logger.SetLevel(logLevel)
+			if flags.LogFormat == logFormatJSON {
+				logger.SetFormatter(&log.JSONFormatter{})
+			}
+		}
 		return nil
 	}
 
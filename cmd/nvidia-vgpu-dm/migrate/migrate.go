@@ -0,0 +1,133 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package migrate
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/sirupsen/logrus"
+	cli "github.com/urfave/cli/v2"
+	"sigs.k8s.io/yaml"
+
+	v1 "github.com/NVIDIA/vgpu-device-manager/api/spec/v1"
+	"github.com/NVIDIA/vgpu-device-manager/cmd/nvidia-vgpu-dm/assert"
+	"github.com/NVIDIA/vgpu-device-manager/pkg/spec"
+)
+
+var log = logrus.New()
+
+// GetLogger returns the logger for the 'migrate' command
+func GetLogger() *logrus.Logger {
+	return log
+}
+
+// Flags for the 'migrate' command
+type Flags struct {
+	ConfigFile   string
+	ConfigFormat string
+	OutputFile   string
+}
+
+// BuildCommand builds the 'migrate' command
+func BuildCommand() *cli.Command {
+	migrateFlags := Flags{}
+
+	migrate := cli.Command{}
+	migrate.Name = "migrate"
+	migrate.Usage = "Convert a configuration file of an older version into the current version"
+	migrate.Action = func(c *cli.Context) error {
+		return migrateWrapper(c, &migrateFlags)
+	}
+
+	migrate.Flags = []cli.Flag{
+		&cli.StringFlag{
+			Name:        "output-file",
+			Aliases:     []string{"o"},
+			Value:       "-",
+			Usage:       "Path to write the migrated configuration file to, or '-' for stdout",
+			Destination: &migrateFlags.OutputFile,
+			EnvVars:     []string{"VGPU_DM_OUTPUT_FILE"},
+		},
+	}
+
+	// Also accept the top-level config flags after the subcommand name.
+	migrate.Flags = append(migrate.Flags, assert.ConfigFileFlags()...)
+
+	return &migrate
+}
+
+// CheckFlags ensures that any required flags are provided and ensures they are well-formed.
+// The 'config-file' and 'config-format' flags are read from whichever command in the context lineage of 'c' they were set on.
+func CheckFlags(c *cli.Context, f *Flags) error {
+	f.ConfigFile = assert.LookupString(c, "config-file")
+	f.ConfigFormat = assert.LookupString(c, "config-format")
+	if f.ConfigFile == "" {
+		return fmt.Errorf("missing required flags 'config-file'")
+	}
+	if f.ConfigFormat == "" {
+		f.ConfigFormat = spec.DetectFormat(f.ConfigFile)
+	}
+	if !spec.IsSupportedFormat(f.ConfigFormat) {
+		return fmt.Errorf("invalid value for 'config-format': %v", f.ConfigFormat)
+	}
+	if f.OutputFile == "" {
+		return fmt.Errorf("invalid value for 'output-file': must not be empty")
+	}
+	return nil
+}
+
+func migrateWrapper(c *cli.Context, f *Flags) error {
+	err := CheckFlags(c, f)
+	if err != nil {
+		_ = cli.ShowSubcommandHelp(c)
+		return err
+	}
+
+	log.Debugf("Reading config file...")
+	configYaml, err := assert.ReadConfigFile(&assert.Flags{ConfigFile: f.ConfigFile})
+	if err != nil {
+		return fmt.Errorf("error reading config file: %v", err)
+	}
+
+	configJSON, err := spec.ToJSON(configYaml, f.ConfigFormat)
+	if err != nil {
+		return fmt.Errorf("error reading config file: %v", err)
+	}
+
+	log.Debugf("Migrating config file to version %v...", v1.Version)
+	migrated, err := spec.Migrate(configJSON)
+	if err != nil {
+		return fmt.Errorf("error migrating config file: %v", err)
+	}
+
+	output, err := yaml.Marshal(migrated)
+	if err != nil {
+		return fmt.Errorf("error marshaling migrated config file: %v", err)
+	}
+
+	if f.OutputFile == "-" {
+		_, err = os.Stdout.Write(output)
+	} else {
+		err = os.WriteFile(f.OutputFile, output, 0644)
+	}
+	if err != nil {
+		return fmt.Errorf("error writing migrated config file: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,137 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package status
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/sirupsen/logrus"
+	cli "github.com/urfave/cli/v2"
+
+	"github.com/NVIDIA/vgpu-device-manager/pkg/vgpu"
+)
+
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+)
+
+var log = logrus.New()
+
+// GetLogger returns the logger for the 'status' command
+func GetLogger() *logrus.Logger {
+	return log
+}
+
+// Flags for the 'status' command
+type Flags struct {
+	Output string
+}
+
+// BuildCommand builds the 'status' command
+func BuildCommand() *cli.Command {
+	statusFlags := Flags{}
+
+	status := cli.Command{}
+	status.Name = "status"
+	status.Usage = "Show the vGPU capacity of each GPU on the node"
+	status.Action = func(c *cli.Context) error {
+		return statusWrapper(c, &statusFlags)
+	}
+
+	status.Flags = []cli.Flag{
+		&cli.StringFlag{
+			Name:        "output",
+			Aliases:     []string{"o"},
+			Value:       outputTable,
+			Usage:       "The output format, one of 'table' or 'json'",
+			Destination: &statusFlags.Output,
+			EnvVars:     []string{"VGPU_DM_OUTPUT"},
+		},
+	}
+
+	return &status
+}
+
+// CheckFlags ensures that any required flags are provided and ensures they are well-formed.
+func CheckFlags(f *Flags) error {
+	switch f.Output {
+	case outputTable, outputJSON:
+	default:
+		return fmt.Errorf("invalid value for 'output': %v", f.Output)
+	}
+	return nil
+}
+
+func statusWrapper(c *cli.Context, f *Flags) error {
+	err := CheckFlags(f)
+	if err != nil {
+		_ = cli.ShowSubcommandHelp(c)
+		return err
+	}
+
+	log.Debugf("Getting vGPU capacity of each GPU...")
+	configManager := vgpu.NewNvlibVGPUConfigManager()
+	report, err := configManager.GetVGPUCapacityReport()
+	if err != nil {
+		return fmt.Errorf("error getting vGPU capacity: %v", err)
+	}
+
+	if f.Output == outputJSON {
+		output, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("error marshaling vGPU capacity: %v", err)
+		}
+		fmt.Println(string(output))
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "GPU\tPCI Address\tParent Devices\tActive Devices\tAvailable Instances")
+	for _, capacity := range report {
+		fmt.Fprintf(w, "%d\t%s\t%d\t%d\t%s\n", capacity.GPU, capacity.PCIAddress, capacity.ParentDevices, capacity.ActiveDevices, formatAvailableInstances(capacity.AvailableInstances))
+	}
+
+	err = w.Flush()
+	if err != nil {
+		return fmt.Errorf("error writing vGPU capacity: %v", err)
+	}
+
+	return nil
+}
+
+// formatAvailableInstances renders the vGPU types that can still be created as a sorted, comma-separated
+// list of <type>=<count> pairs. Types with no available instances are left out.
+func formatAvailableInstances(available map[string]int) string {
+	var entries []string
+	for vgpuType, count := range available {
+		if count > 0 {
+			entries = append(entries, fmt.Sprintf("%s=%d", vgpuType, count))
+		}
+	}
+	if len(entries) == 0 {
+		return "-"
+	}
+	sort.Strings(entries)
+
+	return strings.Join(entries, ",")
+}
@@ -25,6 +25,25 @@ var version = "unknown"
 // and will be populated by the Makefile
 var gitCommit = ""
 
+// buildDate will be the UTC date the binary was built on
+// and will be populated by the Makefile
+var buildDate = ""
+
+// GetVersion returns the version the binary was built with
+func GetVersion() string {
+	return version
+}
+
+// GetCommit returns the git commit the binary was built from
+func GetCommit() string {
+	return gitCommit
+}
+
+// GetBuildDate returns the date the binary was built on
+func GetBuildDate() string {
+	return buildDate
+}
+
 // GetVersionParts returns the different version components
 func GetVersionParts() []string {
 	v := []string{version}
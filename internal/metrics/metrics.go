@@ -0,0 +1,84 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "vgpu_dm"
+
+// Metrics holds the Prometheus metrics exported by nvidia-k8s-vgpu-dm
+type Metrics struct {
+	registry      *prometheus.Registry
+	applyTotal    *prometheus.CounterVec
+	applyDuration prometheus.Histogram
+	configState   *prometheus.GaugeVec
+}
+
+// New creates a new set of metrics, registered with their own registry
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		applyTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "config_apply_total",
+			Help:      "Number of vGPU config applications, by status.",
+		}, []string{"status"}),
+		applyDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "config_apply_duration_seconds",
+			Help:      "Time taken to apply a vGPU config.",
+			Buckets:   prometheus.ExponentialBuckets(1, 2, 12),
+		}),
+		configState: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "config_state",
+			Help:      "Whether a vGPU config is currently applied (1) or not (0).",
+		}, []string{"config"}),
+	}
+	m.registry.MustRegister(m.applyTotal, m.applyDuration, m.configState)
+	return m
+}
+
+// RecordConfigApply records the outcome of applying the vGPU config 'config'.
+// On success, 'config' becomes the only config reported as applied. On failure,
+// 'config' is reported as not applied, leaving the state of all other configs as is.
+func (m *Metrics) RecordConfigApply(config string, duration time.Duration, err error) {
+	status := "success"
+	if err != nil {
+		status = "failed"
+	}
+	m.applyTotal.WithLabelValues(status).Inc()
+	m.applyDuration.Observe(duration.Seconds())
+
+	if err != nil {
+		m.configState.WithLabelValues(config).Set(0)
+		return
+	}
+	m.configState.Reset()
+	m.configState.WithLabelValues(config).Set(1)
+}
+
+// Handler returns an HTTP handler serving the metrics
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
@@ -0,0 +1,61 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metrics
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordConfigApply(t *testing.T) {
+	m := New()
+
+	m.RecordConfigApply("A100-4C", 10*time.Second, nil)
+	m.RecordConfigApply("A100-5C", 20*time.Second, fmt.Errorf("apply failed"))
+
+	expected := `
+# HELP vgpu_dm_config_apply_total Number of vGPU config applications, by status.
+# TYPE vgpu_dm_config_apply_total counter
+vgpu_dm_config_apply_total{status="failed"} 1
+vgpu_dm_config_apply_total{status="success"} 1
+# HELP vgpu_dm_config_state Whether a vGPU config is currently applied (1) or not (0).
+# TYPE vgpu_dm_config_state gauge
+vgpu_dm_config_state{config="A100-4C"} 1
+vgpu_dm_config_state{config="A100-5C"} 0
+`
+	err := testutil.GatherAndCompare(m.registry, strings.NewReader(expected), "vgpu_dm_config_apply_total", "vgpu_dm_config_state")
+	require.Nil(t, err)
+
+	m.RecordConfigApply("A100-4C", 5*time.Second, nil)
+	require.Equal(t, float64(1), testutil.ToFloat64(m.configState.WithLabelValues("A100-4C")))
+	require.Equal(t, 1, testutil.CollectAndCount(m.configState))
+
+	families, err := m.registry.Gather()
+	require.Nil(t, err)
+	var sampleCount uint64
+	for _, family := range families {
+		if family.GetName() == "vgpu_dm_config_apply_duration_seconds" {
+			sampleCount = family.GetMetric()[0].GetHistogram().GetSampleCount()
+		}
+	}
+	require.Equal(t, uint64(3), sampleCount)
+}
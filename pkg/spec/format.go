@@ -0,0 +1,191 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package spec
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	log "github.com/sirupsen/logrus"
+	"sigs.k8s.io/yaml"
+
+	v1 "github.com/NVIDIA/vgpu-device-manager/api/spec/v1"
+	"github.com/NVIDIA/vgpu-device-manager/pkg/types"
+)
+
+// Formats supported for config files. The TOML and JSON schemas mirror the YAML schema exactly.
+const (
+	FormatYAML = "yaml"
+	FormatJSON = "json"
+	FormatTOML = "toml"
+)
+
+// IsSupportedFormat checks whether 'format' is a config file format that can be parsed by this package.
+func IsSupportedFormat(format string) bool {
+	switch format {
+	case FormatYAML, FormatJSON, FormatTOML:
+		return true
+	}
+	return false
+}
+
+// DetectFormat returns the format of the config file at 'path' based on its extension.
+// Files with neither a '.toml' nor a '.json' extension (including stdin) are treated as YAML.
+func DetectFormat(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".toml":
+		return FormatTOML
+	case ".json":
+		return FormatJSON
+	}
+	return FormatYAML
+}
+
+// ToJSON converts the contents of a config file in 'format' into JSON.
+func ToJSON(data []byte, format string) ([]byte, error) {
+	switch format {
+	case FormatYAML:
+		return yaml.YAMLToJSON(data)
+	case FormatJSON:
+		return data, nil
+	case FormatTOML:
+		var config map[string]interface{}
+		_, err := toml.Decode(string(data), &config)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(config)
+	}
+	return nil, fmt.Errorf("unsupported config format: %v", format)
+}
+
+// ValidationMode controls how vGPU types that cannot be parsed are treated when parsing a config file.
+type ValidationMode string
+
+const (
+	// ValidationModeStrict rejects any config file containing an unrecognised vGPU type.
+	ValidationModeStrict ValidationMode = "strict"
+	// ValidationModePermissive skips unrecognised vGPU types with a warning, e.g. for types that
+	// are supported by a newer driver but not by this version of the vGPU Device Manager.
+	ValidationModePermissive ValidationMode = "permissive"
+)
+
+// IsValidValidationMode checks whether 'mode' is a known 'ValidationMode'.
+func IsValidValidationMode(mode string) bool {
+	return mode == string(ValidationModeStrict) || mode == string(ValidationModePermissive)
+}
+
+// ParseOption is a function for passing options to 'Parse'.
+type ParseOption func(*parseOptions)
+
+type parseOptions struct {
+	validationMode ValidationMode
+}
+
+// WithValidationMode provides a 'ParseOption' that sets the 'ValidationMode' of a parse.
+// An unknown mode is treated as 'ValidationModeStrict', which is also the default.
+func WithValidationMode(mode ValidationMode) ParseOption {
+	return func(o *parseOptions) {
+		o.validationMode = mode
+	}
+}
+
+// Parse parses the contents of a config file in 'format' into a 'Spec'.
+func Parse(data []byte, format string, opts ...ParseOption) (*v1.Spec, error) {
+	o := parseOptions{
+		validationMode: ValidationModeStrict,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	configJSON, err := ToJSON(data, format)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshal error: %v", err)
+	}
+
+	if o.validationMode == ValidationModePermissive {
+		configJSON, err = skipUnknownVGPUTypes(configJSON)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var spec v1.Spec
+	err = json.Unmarshal(configJSON, &spec)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshal error: %v", err)
+	}
+
+	return &spec, nil
+}
+
+// skipUnknownVGPUTypes removes the vGPU types that cannot be parsed from every 'vgpu-devices' field of a config
+// file in JSON, logging a warning for each of them. It returns an error if none of the vGPU types in a field can be
+// parsed, rather than leaving it empty. Config files that do not have the expected structure are returned as-is,
+// so that unmarshalling them reports the error.
+func skipUnknownVGPUTypes(configJSON []byte) ([]byte, error) {
+	var config map[string]json.RawMessage
+	if err := json.Unmarshal(configJSON, &config); err != nil {
+		return configJSON, nil
+	}
+	var vgpuConfigs map[string][]map[string]json.RawMessage
+	if err := json.Unmarshal(config["vgpu-configs"], &vgpuConfigs); err != nil {
+		return configJSON, nil
+	}
+
+	for name, entries := range vgpuConfigs {
+		for i, entry := range entries {
+			if _, exists := entry["vgpu-devices"]; !exists {
+				continue
+			}
+			var devices map[string]json.RawMessage
+			if err := json.Unmarshal(entry["vgpu-devices"], &devices); err != nil {
+				return configJSON, nil
+			}
+
+			known := make(map[string]json.RawMessage)
+			for key, val := range devices {
+				if _, err := types.ParseVGPUType(key); err != nil {
+					log.Warnf("Skipping unrecognised vGPU type '%v' in vgpu-configs.%s[%d]: %v", key, name, i, err)
+					continue
+				}
+				known[key] = val
+			}
+			if len(known) == 0 && len(devices) > 0 {
+				return nil, fmt.Errorf("vgpu-configs.%s[%d].vgpu-devices: no recognised vGPU types", name, i)
+			}
+
+			raw, err := json.Marshal(known)
+			if err != nil {
+				return nil, err
+			}
+			entry["vgpu-devices"] = raw
+		}
+	}
+
+	raw, err := json.Marshal(vgpuConfigs)
+	if err != nil {
+		return nil, err
+	}
+	config["vgpu-configs"] = raw
+
+	return json.Marshal(config)
+}
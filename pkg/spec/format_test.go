@@ -0,0 +1,211 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package spec
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/BurntSushi/toml"
+	"github.com/stretchr/testify/require"
+
+	"github.com/NVIDIA/vgpu-device-manager/pkg/types"
+)
+
+func TestDetectFormat(t *testing.T) {
+	testCases := []struct {
+		path     string
+		expected string
+	}{
+		{"config.yaml", FormatYAML},
+		{"config.yml", FormatYAML},
+		{"config.json", FormatJSON},
+		{"config.toml", FormatTOML},
+		{"/etc/vgpu/CONFIG.TOML", FormatTOML},
+		{"config", FormatYAML},
+		{"-", FormatYAML},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			require.Equal(t, tc.expected, DetectFormat(tc.path))
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		description     string
+		format          string
+		config          string
+		expectedFailure bool
+	}{
+		{
+			"YAML",
+			FormatYAML,
+			"version: v1\nvgpu-configs:\n  default:\n  - devices: all\n    vgpu-devices:\n      A100-4C: 10\n",
+			false,
+		},
+		{
+			"JSON",
+			FormatJSON,
+			`{"version": "v1", "vgpu-configs": {"default": [{"devices": "all", "vgpu-devices": {"A100-4C": 10}}]}}`,
+			false,
+		},
+		{
+			"TOML",
+			FormatTOML,
+			"version = \"v1\"\n[[vgpu-configs.default]]\ndevices = \"all\"\n[vgpu-configs.default.vgpu-devices]\n\"A100-4C\" = 10\n",
+			false,
+		},
+		{
+			"Malformed TOML",
+			FormatTOML,
+			"version = v1\n",
+			true,
+		},
+		{
+			"TOML with an unexpected field",
+			FormatTOML,
+			"version = \"v1\"\nbogus = 1\n",
+			true,
+		},
+		{
+			"Unsupported format",
+			"ini",
+			"version = v1\n",
+			true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			s, err := Parse([]byte(tc.config), tc.format)
+			if tc.expectedFailure {
+				require.Error(t, err)
+				return
+			}
+			require.Nil(t, err)
+			require.Equal(t, []string{"default"}, s.ConfigNames())
+		})
+	}
+}
+
+func TestParseValidationMode(t *testing.T) {
+	testCases := []struct {
+		description     string
+		mode            ValidationMode
+		vgpuDevices     string
+		expectedFailure bool
+		expected        types.VGPUConfig
+	}{
+		{
+			"Default with a future series",
+			"",
+			`{"A100-4C": 5, "A100-4X": 5}`,
+			true,
+			nil,
+		},
+		{
+			"Strict with only known types",
+			ValidationModeStrict,
+			`{"A100-4C": 10}`,
+			false,
+			types.VGPUConfig{"A100-4C": 10},
+		},
+		{
+			"Strict with a future series",
+			ValidationModeStrict,
+			`{"A100-4C": 5, "A100-4X": 5}`,
+			true,
+			nil,
+		},
+		{
+			"Permissive with a future series",
+			ValidationModePermissive,
+			`{"A100-4C": 5, "A100-4X": 5}`,
+			false,
+			types.VGPUConfig{"A100-4C": 5},
+		},
+		{
+			"Permissive with only a future series",
+			ValidationModePermissive,
+			`{"A100-4X": 5}`,
+			true,
+			nil,
+		},
+		{
+			"Permissive with an invalid count",
+			ValidationModePermissive,
+			`{"A100-4C": 0, "A100-4X": 5}`,
+			true,
+			nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			config := `{"version": "v1", "vgpu-configs": {"default": [{"devices": "all", "comment": "test", "vgpu-devices": ` + tc.vgpuDevices + `}]}}`
+
+			var opts []ParseOption
+			if tc.mode != "" {
+				opts = append(opts, WithValidationMode(tc.mode))
+			}
+			s, err := Parse([]byte(config), FormatJSON, opts...)
+			if tc.expectedFailure {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			vgpuConfig, exists := s.GetConfig("default")
+			require.True(t, exists)
+			require.Len(t, vgpuConfig, 1)
+			require.Equal(t, tc.expected, vgpuConfig[0].VGPUDevices)
+			require.Equal(t, "test", vgpuConfig[0].Comment)
+		})
+	}
+
+	// The validation mode of one parse does not leak into the next
+	_, err := Parse([]byte(`{"version": "v1", "vgpu-configs": {"default": [{"devices": "all", "vgpu-devices": {"A100-4C": 5, "A100-4X": 5}}]}}`), FormatJSON)
+	require.Error(t, err)
+}
+
+func TestParseTOMLRoundTrip(t *testing.T) {
+	input, err := os.ReadFile(filepath.Join("testdata", "migrate-v1.yaml"))
+	require.Nil(t, err)
+
+	fromYAML, err := Parse(input, FormatYAML)
+	require.Nil(t, err)
+
+	// Encode the parsed 'Spec' as TOML through its generic JSON representation.
+	configJSON, err := json.Marshal(fromYAML)
+	require.Nil(t, err)
+	var config map[string]interface{}
+	err = json.Unmarshal(configJSON, &config)
+	require.Nil(t, err)
+	var configTOML bytes.Buffer
+	err = toml.NewEncoder(&configTOML).Encode(config)
+	require.Nil(t, err)
+
+	fromTOML, err := Parse(configTOML.Bytes(), FormatTOML)
+	require.Nil(t, err)
+	require.Equal(t, fromYAML, fromTOML)
+}
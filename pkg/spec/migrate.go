@@ -0,0 +1,121 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package spec
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"sigs.k8s.io/yaml"
+
+	v1 "github.com/NVIDIA/vgpu-device-manager/api/spec/v1"
+)
+
+// Migrator upgrades a config file from one version of the 'Spec' to another.
+// Configs are passed around as generic maps so that versions which can no
+// longer be unmarshalled into a 'Spec' can still be read and converted.
+type Migrator interface {
+	// From returns the version of the configs accepted by 'Migrate'.
+	From() string
+	// To returns the version of the configs returned by 'Migrate'.
+	To() string
+	// Migrate converts a config of version 'From' into one of version 'To'.
+	Migrate(config map[string]interface{}) (map[string]interface{}, error)
+}
+
+// migrators holds the registered 'Migrator's, keyed by the version they migrate from.
+var migrators = make(map[string]Migrator)
+
+func init() {
+	RegisterMigrator(identityMigrator{v1.Version})
+}
+
+// RegisterMigrator registers a 'Migrator' for the version returned by its 'From' method.
+// It panics if a 'Migrator' is already registered for that version.
+func RegisterMigrator(m Migrator) {
+	if _, exists := migrators[m.From()]; exists {
+		panic(fmt.Sprintf("migrator already registered for version %v", m.From()))
+	}
+	migrators[m.From()] = m
+}
+
+// Migrate reads a config file of any version with a registered 'Migrator' and
+// returns an equivalent 'Spec' of the current version. Migrators are chained
+// (i.e. v1 -> v2 -> v3) until one of them produces the current version.
+func Migrate(configYaml []byte) (*v1.Spec, error) {
+	var config map[string]interface{}
+	err := yaml.Unmarshal(configYaml, &config)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshal error: %v", err)
+	}
+
+	for steps := 0; ; steps++ {
+		version, _ := config["version"].(string)
+		if version == "" {
+			return nil, fmt.Errorf("unable to migrate config with missing 'version' field")
+		}
+		if steps >= len(migrators) {
+			return nil, fmt.Errorf("unable to migrate config from version %v to %v: no migration path", version, v1.Version)
+		}
+
+		m, exists := migrators[version]
+		if !exists {
+			return nil, fmt.Errorf("unable to migrate config from unknown version: %v", version)
+		}
+
+		config, err = m.Migrate(config)
+		if err != nil {
+			return nil, fmt.Errorf("error migrating config from version %v to %v: %v", m.From(), m.To(), err)
+		}
+		config["version"] = m.To()
+
+		if m.To() == v1.Version {
+			break
+		}
+	}
+
+	b, err := json.Marshal(config)
+	if err != nil {
+		return nil, fmt.Errorf("marshal error: %v", err)
+	}
+
+	var spec v1.Spec
+	err = json.Unmarshal(b, &spec)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing migrated config: %v", err)
+	}
+
+	return &spec, nil
+}
+
+// identityMigrator migrates a config to the same version it is already in.
+// It terminates the migration chain for the current version.
+type identityMigrator struct {
+	version string
+}
+
+func (m identityMigrator) From() string {
+	return m.version
+}
+
+func (m identityMigrator) To() string {
+	return m.version
+}
+
+func (m identityMigrator) Migrate(config map[string]interface{}) (map[string]interface{}, error) {
+	return config, nil
+}
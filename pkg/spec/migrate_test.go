@@ -0,0 +1,122 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package spec
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/yaml"
+
+	v1 "github.com/NVIDIA/vgpu-device-manager/api/spec/v1"
+)
+
+func TestMigrateGolden(t *testing.T) {
+	input, err := os.ReadFile(filepath.Join("testdata", "migrate-v1.yaml"))
+	require.Nil(t, err)
+	golden, err := os.ReadFile(filepath.Join("testdata", "migrate-v1.golden.yaml"))
+	require.Nil(t, err)
+
+	migrated, err := Migrate(input)
+	require.Nil(t, err)
+	require.Equal(t, v1.Version, migrated.Version)
+	require.Equal(t, []string{"A100-4C", "H100-1-10C", "custom"}, migrated.ConfigNames())
+
+	output, err := yaml.Marshal(migrated)
+	require.Nil(t, err)
+	require.Equal(t, string(golden), string(output))
+
+	var original v1.Spec
+	err = yaml.Unmarshal(input, &original)
+	require.Nil(t, err)
+	require.Equal(t, &original, migrated)
+}
+
+func TestMigrate(t *testing.T) {
+	testCases := []struct {
+		description     string
+		config          string
+		expectedFailure bool
+	}{
+		{
+			"Current version",
+			"version: v1\nvgpu-configs:\n  default:\n  - devices: all\n    vgpu-devices:\n      A100-4C: 10\n",
+			false,
+		},
+		{
+			"Older version with a registered migrator",
+			"version: v0\nvgpu-configs:\n  default:\n  - devices: all\n    vgpu-devices:\n      A100-4C: 10\n",
+			false,
+		},
+		{
+			"Missing version",
+			"vgpu-configs:\n  default:\n  - devices: all\n    vgpu-devices:\n      A100-4C: 10\n",
+			true,
+		},
+		{
+			"Unknown version",
+			"version: v9\nvgpu-configs:\n  default:\n  - devices: all\n    vgpu-devices:\n      A100-4C: 10\n",
+			true,
+		},
+		{
+			"Invalid config after migration",
+			"version: v1\nvgpu-configs:\n  default:\n  - devices: some\n    vgpu-devices:\n      A100-4C: 10\n",
+			true,
+		},
+	}
+
+	RegisterMigrator(testMigrator{from: "v0", to: v1.Version})
+	defer delete(migrators, "v0")
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			migrated, err := Migrate([]byte(tc.config))
+			if tc.expectedFailure {
+				require.Error(t, err)
+				return
+			}
+			require.Nil(t, err)
+			require.Equal(t, v1.Version, migrated.Version)
+			require.Equal(t, []string{"default"}, migrated.ConfigNames())
+		})
+	}
+}
+
+func TestRegisterMigratorDuplicate(t *testing.T) {
+	require.Panics(t, func() {
+		RegisterMigrator(testMigrator{from: v1.Version, to: v1.Version})
+	})
+}
+
+type testMigrator struct {
+	from string
+	to   string
+}
+
+func (m testMigrator) From() string {
+	return m.from
+}
+
+func (m testMigrator) To() string {
+	return m.to
+}
+
+func (m testMigrator) Migrate(config map[string]interface{}) (map[string]interface{}, error) {
+	return config, nil
+}
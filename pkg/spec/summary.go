@@ -0,0 +1,70 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package spec
+
+import (
+	"sort"
+
+	v1 "github.com/NVIDIA/vgpu-device-manager/api/spec/v1"
+	"github.com/NVIDIA/vgpu-device-manager/pkg/types"
+)
+
+// ConfigSummary briefly describes a single named config in a 'Spec'.
+type ConfigSummary struct {
+	Name          string   `json:"name"`
+	Entries       int      `json:"entries"`
+	MIGBacked     bool     `json:"migBacked"`
+	DeviceFilters []string `json:"deviceFilters,omitempty"`
+}
+
+// Summarize returns a 'ConfigSummary' for each named config in a 'Spec', sorted by name.
+func Summarize(spec *v1.Spec) []ConfigSummary {
+	var summaries []ConfigSummary
+	for _, name := range spec.ConfigNames() {
+		configs := spec.VGPUConfigs[name]
+		summary := ConfigSummary{
+			Name:    name,
+			Entries: len(configs),
+		}
+
+		filters := make(map[string]bool)
+		for _, vs := range configs {
+			for key := range vs.VGPUDevices {
+				vgpuType, err := types.ParseVGPUType(key)
+				if err == nil && vgpuType.IsMIGBacked() {
+					summary.MIGBacked = true
+				}
+			}
+
+			switch deviceFilter := vs.DeviceFilter.(type) {
+			case string:
+				filters[deviceFilter] = true
+			case []string:
+				for _, df := range deviceFilter {
+					filters[df] = true
+				}
+			}
+		}
+		for df := range filters {
+			summary.DeviceFilters = append(summary.DeviceFilters, df)
+		}
+		sort.Strings(summary.DeviceFilters)
+
+		summaries = append(summaries, summary)
+	}
+	return summaries
+}
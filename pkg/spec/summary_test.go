@@ -0,0 +1,77 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package spec
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	v1 "github.com/NVIDIA/vgpu-device-manager/api/spec/v1"
+	"github.com/NVIDIA/vgpu-device-manager/pkg/types"
+)
+
+func TestSummarize(t *testing.T) {
+	spec := &v1.Spec{
+		Version: v1.Version,
+		VGPUConfigs: map[string]v1.VGPUConfigSpecSlice{
+			"time-sliced": {
+				{
+					Devices:     "all",
+					VGPUDevices: types.MustNewVGPUConfig(types.VGPUConfigEntry{Type: "A100-4C", Count: 10}),
+				},
+			},
+			"mixed": {
+				{
+					DeviceFilter: []string{"0x20B010DE", "0x20B510DE"},
+					Devices:      "all",
+					VGPUDevices:  types.MustNewVGPUConfig(types.VGPUConfigEntry{Type: "A100-1-5C", Count: 7}),
+				},
+				{
+					DeviceFilter: "0x1EB810DE",
+					Devices:      []int{1},
+					VGPUDevices:  types.MustNewVGPUConfig(types.VGPUConfigEntry{Type: "T4-1Q", Count: 16}),
+				},
+			},
+		},
+	}
+
+	expected := []ConfigSummary{
+		{
+			Name:          "mixed",
+			Entries:       2,
+			MIGBacked:     true,
+			DeviceFilters: []string{"0x1EB810DE", "0x20B010DE", "0x20B510DE"},
+		},
+		{
+			Name:    "time-sliced",
+			Entries: 1,
+		},
+	}
+
+	summaries := Summarize(spec)
+	require.Equal(t, expected, summaries)
+
+	output, err := json.Marshal(summaries)
+	require.Nil(t, err)
+
+	var roundtrip []ConfigSummary
+	err = json.Unmarshal(output, &roundtrip)
+	require.Nil(t, err)
+	require.Equal(t, summaries, roundtrip)
+}
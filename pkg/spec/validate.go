@@ -0,0 +1,165 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package spec
+
+import (
+	"fmt"
+	"sort"
+
+	v1 "github.com/NVIDIA/vgpu-device-manager/api/spec/v1"
+	"github.com/NVIDIA/vgpu-device-manager/pkg/types"
+)
+
+// ValidationError describes a single problem found while validating a 'Spec'.
+// The 'Path' identifies the offending field (e.g. "vgpu-configs.default[0].devices").
+type ValidationError struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// Error returns a 'ValidationError' as a string.
+func (e ValidationError) Error() string {
+	if e.Path == "" {
+		return e.Message
+	}
+	return fmt.Sprintf("%s: %s", e.Path, e.Message)
+}
+
+// Validate checks a 'Spec' and returns all validation errors found in it.
+// Unlike unmarshalling a 'Spec', it does not stop at the first error.
+func Validate(spec *v1.Spec) []ValidationError {
+	var errs []ValidationError
+
+	if spec == nil {
+		return append(errs, ValidationError{Message: "spec is nil"})
+	}
+
+	if !v1.IsSupported(spec.Version) {
+		errs = append(errs, ValidationError{"version", fmt.Sprintf("unknown version: %v", spec.Version)})
+	}
+
+	for _, name := range spec.ConfigNames() {
+		path := fmt.Sprintf("vgpu-configs.%s", name)
+		configs := spec.VGPUConfigs[name]
+		if len(configs) == 0 {
+			errs = append(errs, ValidationError{path, "at least one entry is required"})
+			continue
+		}
+		for i := range configs {
+			errs = append(errs, validateVGPUConfigSpec(fmt.Sprintf("%s[%d]", path, i), &configs[i])...)
+		}
+	}
+
+	return errs
+}
+
+func validateVGPUConfigSpec(path string, vs *v1.VGPUConfigSpec) []ValidationError {
+	var errs []ValidationError
+
+	switch deviceFilter := vs.DeviceFilter.(type) {
+	case nil, string:
+	case []string:
+		if len(deviceFilter) > 1 {
+			for _, df := range deviceFilter {
+				if df == v1.DeviceFilterNone {
+					errs = append(errs, ValidationError{path + ".device-filter", fmt.Sprintf("'%v' cannot be combined with other values", v1.DeviceFilterNone)})
+					break
+				}
+			}
+		}
+	default:
+		errs = append(errs, ValidationError{path + ".device-filter", fmt.Sprintf("invalid type: %T", vs.DeviceFilter)})
+	}
+
+	switch modelFilter := vs.ModelFilter.(type) {
+	case nil:
+	case string:
+		if modelFilter == "" {
+			errs = append(errs, ValidationError{path + ".model-filter", "empty string input"})
+		}
+	case []string:
+		for _, mf := range modelFilter {
+			if mf == "" {
+				errs = append(errs, ValidationError{path + ".model-filter", "empty string input"})
+				break
+			}
+		}
+	default:
+		errs = append(errs, ValidationError{path + ".model-filter", fmt.Sprintf("invalid type: %T", vs.ModelFilter)})
+	}
+
+	switch devices := vs.Devices.(type) {
+	case nil:
+		errs = append(errs, ValidationError{path + ".devices", "missing required field"})
+	case string:
+		if devices != "all" {
+			errs = append(errs, ValidationError{path + ".devices", fmt.Sprintf("invalid string input: %v", devices)})
+		}
+	case []int:
+		for _, d := range devices {
+			if d < 0 {
+				errs = append(errs, ValidationError{path + ".devices", fmt.Sprintf("invalid device index: %v", d)})
+			}
+		}
+	default:
+		errs = append(errs, ValidationError{path + ".devices", fmt.Sprintf("invalid type: %T", vs.Devices)})
+	}
+
+	errs = append(errs, validateVGPUConfig(path+".vgpu-devices", vs.VGPUDevices)...)
+
+	return errs
+}
+
+func validateVGPUConfig(path string, config types.VGPUConfig) []ValidationError {
+	var errs []ValidationError
+
+	if config == nil {
+		return append(errs, ValidationError{path, "missing required field"})
+	}
+
+	var keys []string
+	for key := range config {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	timeSliced := 0
+	migBacked := 0
+	for _, key := range keys {
+		vgpuType, err := types.ParseVGPUType(key)
+		if err != nil {
+			errs = append(errs, ValidationError{path + "." + key, fmt.Sprintf("invalid format: %v", err)})
+		} else if vgpuType.IsMIGBacked() {
+			migBacked++
+		} else {
+			timeSliced++
+		}
+		if config[key] == types.VGPUCountMax {
+			if len(config) > 1 {
+				errs = append(errs, ValidationError{path + "." + key, "count 'max' cannot be combined with other vGPU types"})
+			}
+		} else if config[key] <= 0 {
+			errs = append(errs, ValidationError{path + "." + key, fmt.Sprintf("invalid count: %v", config[key])})
+		}
+	}
+
+	if timeSliced > 0 && migBacked > 0 {
+		errs = append(errs, ValidationError{path, "cannot mix time-sliced and MIG-backed vGPU devices on the same GPU"})
+	}
+
+	return errs
+}
@@ -0,0 +1,125 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package spec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	v1 "github.com/NVIDIA/vgpu-device-manager/api/spec/v1"
+	"github.com/NVIDIA/vgpu-device-manager/pkg/types"
+)
+
+func TestValidate(t *testing.T) {
+	testCases := []struct {
+		description string
+		spec        *v1.Spec
+		paths       []string
+	}{
+		{
+			"Nil spec",
+			nil,
+			[]string{""},
+		},
+		{
+			"Valid spec",
+			&v1.Spec{
+				Version: v1.Version,
+				VGPUConfigs: map[string]v1.VGPUConfigSpecSlice{
+					"default": {
+						{
+							Devices:     "all",
+							VGPUDevices: types.MustNewVGPUConfig(types.VGPUConfigEntry{Type: "A100-4C", Count: 10}),
+						},
+					},
+				},
+			},
+			nil,
+		},
+		{
+			"Valid 'max' count",
+			&v1.Spec{
+				Version: v1.Version,
+				VGPUConfigs: map[string]v1.VGPUConfigSpecSlice{
+					"default": {
+						{
+							Devices:     "all",
+							VGPUDevices: types.VGPUConfig{"A100-4C": types.VGPUCountMax},
+						},
+					},
+				},
+			},
+			nil,
+		},
+		{
+			"Invalid 'max' count combined with other types",
+			&v1.Spec{
+				Version: v1.Version,
+				VGPUConfigs: map[string]v1.VGPUConfigSpecSlice{
+					"default": {
+						{
+							Devices:     "all",
+							VGPUDevices: types.VGPUConfig{"A100-1-5C": types.VGPUCountMax, "A100-2-10C": 1},
+						},
+					},
+				},
+			},
+			[]string{
+				"vgpu-configs.default[0].vgpu-devices.A100-1-5C",
+			},
+		},
+		{
+			"Multiple errors",
+			&v1.Spec{
+				Version: "v2",
+				VGPUConfigs: map[string]v1.VGPUConfigSpecSlice{
+					"empty": {},
+					"default": {
+						{
+							Devices:     "some",
+							VGPUDevices: types.VGPUConfig{"A100-4c": 1, "A100-5C": 0},
+						},
+						{
+							Devices:     []int{0, -1},
+							VGPUDevices: types.VGPUConfig{"A100-4C": 1, "A100-1-5C": 1},
+						},
+					},
+				},
+			},
+			[]string{
+				"version",
+				"vgpu-configs.default[0].devices",
+				"vgpu-configs.default[0].vgpu-devices.A100-4c",
+				"vgpu-configs.default[0].vgpu-devices.A100-5C",
+				"vgpu-configs.default[1].devices",
+				"vgpu-configs.default[1].vgpu-devices",
+				"vgpu-configs.empty",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			var paths []string
+			for _, err := range Validate(tc.spec) {
+				paths = append(paths, err.Path)
+			}
+			require.Equal(t, tc.paths, paths)
+		})
+	}
+}
@@ -32,12 +32,14 @@ const (
 	B
 	// C series vGPU
 	C
+	// D series vGPU
+	D
 )
 
 // IsValid checks whether an ASCII character represents a valid series.
 func (s Series) IsValid() bool {
 	switch s {
-	case A, B, C, Q:
+	case A, B, C, D, Q:
 		return true
 	}
 	return false
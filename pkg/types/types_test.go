@@ -17,6 +17,7 @@
 package types
 
 import (
+	"regexp"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -53,11 +54,31 @@ func TestParseVGPUType(t *testing.T) {
 			"A16-8C",
 			true,
 		},
+		{
+			"Valid D-Series (H100-80D)",
+			"H100-80D",
+			true,
+		},
+		{
+			"Valid MIG-backed D-Series (H100-1-80D)",
+			"H100-1-80D",
+			true,
+		},
 		{
 			"Invalid series",
 			"A16-8E",
 			false,
 		},
+		{
+			"Invalid series (H100-80E)",
+			"H100-80E",
+			false,
+		},
+		{
+			"Invalid MIG-backed series (H100-1-80E)",
+			"H100-1-80E",
+			false,
+		},
 		{
 			"Valid A100-5C",
 			"A100-5C",
@@ -167,6 +188,192 @@ func TestParseVGPUType(t *testing.T) {
 	}
 }
 
+func TestMDEVTypeName(t *testing.T) {
+	testCases := []struct {
+		name     string
+		expected string
+	}{
+		{"A100-4C", "A100-4C"},
+		{"NVIDIA A100-4C\n", "A100-4C"},
+		{"GRID A100-4C", "A100-4C"},
+		{"NVIDIA RTX Pro 6000 Blackwell DC-48C", "RTX Pro 6000 Blackwell DC-48C"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, MDEVTypeName(tc.name))
+		})
+	}
+}
+
+func TestParseVGPUTypeName(t *testing.T) {
+	testCases := []struct {
+		name            string
+		expectedSuccess bool
+		expected        VGPUType
+	}{
+		{"A100-4C", true, VGPUType{GPU: "A100", GB: 4, S: C}},
+		{"NVIDIA A100-4C", true, VGPUType{GPU: "A100", GB: 4, S: C}},
+		{"GRID A100-4C", true, VGPUType{GPU: "A100", GB: 4, S: C}},
+		{"NVIDIA A100-1-5CME", true, VGPUType{GPU: "A100", G: 1, GB: 5, S: C, Attr: []string{AttributeMediaExtensions}}},
+		{"NVIDIA A16-8Q\n", true, VGPUType{GPU: "A16", GB: 8, S: Q}},
+		{"NVIDIA RTX Pro 6000 Blackwell DC-48C", false, VGPUType{}},
+		{"", false, VGPUType{}},
+		{"NVIDIA", false, VGPUType{}},
+		{"NVIDIA A100-4X", false, VGPUType{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			v, err := ParseVGPUTypeName(tc.name)
+			if !tc.expectedSuccess {
+				require.NotNil(t, err)
+				return
+			}
+			require.Nil(t, err)
+			require.Equal(t, tc.expected, *v)
+		})
+	}
+}
+
+func TestVGPUTypeString(t *testing.T) {
+	testCases := []struct {
+		device   string
+		expected VGPUType
+	}{
+		{"A16-8Q", VGPUType{GPU: "A16", GB: 8, S: Q}},
+		{"M60-0Q", VGPUType{GPU: "M60", GB: 0, S: Q}},
+		{"H100-80D", VGPUType{GPU: "H100", GB: 80, S: D}},
+		{"H100-1-80D", VGPUType{GPU: "H100", G: 1, GB: 80, S: D}},
+		{"A100-1-5C", VGPUType{GPU: "A100", G: 1, GB: 5, S: C}},
+		{"A100-1-5CME", VGPUType{GPU: "A100", G: 1, GB: 5, S: C, Attr: []string{AttributeMediaExtensions}}},
+		{"RTX6000-Ada-2Q", VGPUType{GPU: "RTX6000-Ada", GB: 2, S: Q}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.device, func(t *testing.T) {
+			v, err := ParseVGPUType(tc.device)
+			require.Nil(t, err)
+			require.Equal(t, tc.expected, *v)
+			require.Equal(t, tc.device, v.String())
+		})
+	}
+}
+
+func TestIsMIGBacked(t *testing.T) {
+	testCases := []struct {
+		vgpuType           string
+		expectedMIGBacked  bool
+		expectedTimeSliced bool
+	}{
+		{"A100-4C", false, true},
+		{"M60-0Q", false, true},
+		{"RTX6000-Ada-2Q", false, true},
+		{"A100-1-5C", true, false},
+		{"A100-1-5CME", true, false},
+		{"H100-7-80C", true, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.vgpuType, func(t *testing.T) {
+			v, err := ParseVGPUType(tc.vgpuType)
+			require.Nil(t, err)
+			require.Equal(t, tc.expectedMIGBacked, v.IsMIGBacked())
+			require.Equal(t, tc.expectedTimeSliced, v.IsTimeSliced())
+		})
+	}
+}
+
+func TestInstancesPerGB(t *testing.T) {
+	testCases := []struct {
+		vgpuType string
+		expected float64
+	}{
+		{"M60-0Q", 2.0},
+		{"T4-1Q", 1.0},
+		{"A100-4C", 0.25},
+		{"A100-1-5C", 0.2},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.vgpuType, func(t *testing.T) {
+			v, err := ParseVGPUType(tc.vgpuType)
+			require.Nil(t, err)
+			require.Equal(t, tc.expected, v.InstancesPerGB())
+		})
+	}
+}
+
+func TestMemory(t *testing.T) {
+	testCases := []struct {
+		vgpuType   string
+		expectedMB int
+		expectedGB float64
+	}{
+		{"M60-0Q", 512, 0.5},
+		{"T4-1Q", 1024, 1.0},
+		{"A100-4C", 4096, 4.0},
+		{"A100-1-5C", 5120, 5.0},
+		{"H100-80D", 81920, 80.0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.vgpuType, func(t *testing.T) {
+			v, err := ParseVGPUType(tc.vgpuType)
+			require.Nil(t, err)
+			require.Equal(t, tc.expectedMB, v.MemoryMB())
+			require.Equal(t, tc.expectedGB, v.MemoryGB())
+		})
+	}
+}
+
+func TestParseRegex(t *testing.T) {
+	testCases := []struct {
+		description   string
+		re            *regexp.Regexp
+		s             string
+		expectedMatch bool
+		expected      map[string]string
+	}{
+		{
+			"No match",
+			timeSlicedRe,
+			"bogus",
+			false,
+			nil,
+		},
+		{
+			"Time-sliced match",
+			timeSlicedRe,
+			"A100-40C",
+			true,
+			map[string]string{"GPU": "A100", "GB": "40", "S": "C"},
+		},
+		{
+			"MIG-backed match without media extensions",
+			migBackedRe,
+			"A100-1-5C",
+			true,
+			map[string]string{"GPU": "A100", "G": "1", "GB": "5", "S": "C", "ME": ""},
+		},
+		{
+			"Match with all groups empty",
+			regexp.MustCompile("^(?P<A>a?)(?P<B>b?)$"),
+			"",
+			true,
+			map[string]string{"A": "", "B": ""},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			captureGroups, matched := parseRegex(tc.re, tc.s)
+			require.Equal(t, tc.expectedMatch, matched)
+			require.Equal(t, tc.expected, captureGroups)
+		})
+	}
+}
+
 func TestVGPUConfigAssertValid(t *testing.T) {
 	testCases := []struct {
 		description string
@@ -223,6 +430,21 @@ func TestVGPUConfigAssertValid(t *testing.T) {
 			},
 			false,
 		},
+		{
+			"Valid config - 'max' count",
+			map[string]int{
+				"A100-5C": VGPUCountMax,
+			},
+			true,
+		},
+		{
+			"Invalid config - 'max' count combined with other vGPU types",
+			map[string]int{
+				"A100-1-5C":  VGPUCountMax,
+				"A100-2-10C": 1,
+			},
+			false,
+		},
 		{
 			"Valid config - RTX Ada device",
 			map[string]int{
@@ -243,3 +465,131 @@ func TestVGPUConfigAssertValid(t *testing.T) {
 		})
 	}
 }
+
+func TestNewVGPUConfig(t *testing.T) {
+	testCases := []struct {
+		description string
+		entries     []VGPUConfigEntry
+		valid       bool
+	}{
+		{
+			"No entries",
+			nil,
+			true,
+		},
+		{
+			"Valid entries",
+			[]VGPUConfigEntry{{"A100-4C", 5}, {"A100-5C", 4}},
+			true,
+		},
+		{
+			"Invalid vGPU type",
+			[]VGPUConfigEntry{{"A100-4c", 1}},
+			false,
+		},
+		{
+			"Invalid count",
+			[]VGPUConfigEntry{{"A100-4C", 0}},
+			false,
+		},
+		{
+			"Duplicate vGPU type",
+			[]VGPUConfigEntry{{"A100-4C", 1}, {"A100-4C", 2}},
+			false,
+		},
+		{
+			"Both time-sliced and MIG-backed devices",
+			[]VGPUConfigEntry{{"A100-5C", 1}, {"A100-1-5C", 1}},
+			false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			config, err := NewVGPUConfig(tc.entries...)
+			if tc.valid {
+				require.Nil(t, err)
+				require.Len(t, config, len(tc.entries))
+			} else {
+				require.Error(t, err)
+				require.Panics(t, func() { MustNewVGPUConfig(tc.entries...) })
+			}
+		})
+	}
+}
+
+func TestVGPUConfigDiff(t *testing.T) {
+	testCases := []struct {
+		description string
+		current     VGPUConfig
+		other       VGPUConfig
+		expected    VGPUConfigDiff
+		str         string
+	}{
+		{
+			"Empty configs",
+			VGPUConfig{},
+			VGPUConfig{},
+			VGPUConfigDiff{Added: map[string]int{}, Removed: map[string]int{}, Changed: map[string]int{}},
+			"no changes",
+		},
+		{
+			"Equal configs",
+			VGPUConfig{"A100-4C": 10},
+			VGPUConfig{"A100-4C": 10},
+			VGPUConfigDiff{Added: map[string]int{}, Removed: map[string]int{}, Changed: map[string]int{}},
+			"no changes",
+		},
+		{
+			"Added only",
+			VGPUConfig{},
+			VGPUConfig{"A100-4C": 10},
+			VGPUConfigDiff{Added: map[string]int{"A100-4C": 10}, Removed: map[string]int{}, Changed: map[string]int{}},
+			"+A100-4C=10",
+		},
+		{
+			"Removed only",
+			VGPUConfig{"A100-4C": 10},
+			VGPUConfig{},
+			VGPUConfigDiff{Added: map[string]int{}, Removed: map[string]int{"A100-4C": 10}, Changed: map[string]int{}},
+			"-A100-4C=10",
+		},
+		{
+			"Changed only",
+			VGPUConfig{"A100-4C": 10},
+			VGPUConfig{"A100-4C": 5},
+			VGPUConfigDiff{Added: map[string]int{}, Removed: map[string]int{}, Changed: map[string]int{"A100-4C": 5}},
+			"~A100-4C=5",
+		},
+		{
+			"Zero counts are treated as absent",
+			VGPUConfig{"A100-4C": 0, "A100-5C": 2},
+			VGPUConfig{"A100-4C": 3, "A100-5C": 2, "A100-8C": 0},
+			VGPUConfigDiff{Added: map[string]int{"A100-4C": 3}, Removed: map[string]int{}, Changed: map[string]int{}},
+			"+A100-4C=3",
+		},
+		{
+			"Added, removed and changed",
+			VGPUConfig{"A100-4C": 5, "A100-5C": 2, "A100-10C": 1},
+			VGPUConfig{"A100-4C": 3, "A100-8C": 1, "A100-10C": 1},
+			VGPUConfigDiff{Added: map[string]int{"A100-8C": 1}, Removed: map[string]int{"A100-5C": 2}, Changed: map[string]int{"A100-4C": 3}},
+			"~A100-4C=3, -A100-5C=2, +A100-8C=1",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			diff := tc.current.Diff(tc.other)
+			require.Equal(t, tc.expected, diff)
+			require.Equal(t, tc.expected.IsEmpty(), tc.str == "no changes")
+			require.Equal(t, tc.expected.IsEmpty(), diff.IsEmpty())
+			require.Equal(t, tc.str, diff.String())
+		})
+	}
+}
+
+func BenchmarkParseVGPUType(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		_, _ = ParseVGPUType("A100-1-5CME")
+	}
+}
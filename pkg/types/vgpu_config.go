@@ -17,14 +17,67 @@
 package types
 
 import (
+	"encoding/json"
 	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+const (
+	// VGPUCountMax is the count used in a 'VGPUConfig' to request as many
+	// vGPU devices of a type as the GPU supports. It is written as "max".
+	VGPUCountMax = math.MaxInt32
+	// vgpuCountMaxString holds the string representation of 'VGPUCountMax'.
+	vgpuCountMaxString = "max"
 )
 
 // VGPUConfig holds a map of strings representing a vGPU type to a
 // count of that type. It is meant to represent the set of vGPU types
 // (and how many of a particular type) should be instantiated on the GPU.
+// A count of 'VGPUCountMax' requests the maximum supported number of
+// devices of that type, and must be resolved before the config is applied.
 type VGPUConfig map[string]int
 
+// VGPUConfigEntry represents a count of a single vGPU type in a 'VGPUConfig'.
+type VGPUConfigEntry struct {
+	Type  string
+	Count int
+}
+
+// NewVGPUConfig constructs a 'VGPUConfig' from a set of entries, validating each of them.
+func NewVGPUConfig(entries ...VGPUConfigEntry) (VGPUConfig, error) {
+	config := make(VGPUConfig)
+	for _, e := range entries {
+		if _, err := ParseVGPUType(e.Type); err != nil {
+			return nil, fmt.Errorf("invalid format for '%v': %v", e.Type, err)
+		}
+		if e.Count <= 0 {
+			return nil, fmt.Errorf("invalid count for '%v': %v", e.Type, e.Count)
+		}
+		if _, exists := config[e.Type]; exists {
+			return nil, fmt.Errorf("duplicate entry for '%v'", e.Type)
+		}
+		config[e.Type] = e.Count
+	}
+
+	err := config.AssertValid()
+	if err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// MustNewVGPUConfig is like 'NewVGPUConfig' but panics if any of the entries are invalid.
+func MustNewVGPUConfig(entries ...VGPUConfigEntry) VGPUConfig {
+	config, err := NewVGPUConfig(entries...)
+	if err != nil {
+		panic(err)
+	}
+	return config
+}
+
 // AssertValid checks if all the vGPU types making up a 'VGPUConfig' are valid
 func (v VGPUConfig) AssertValid() error {
 	if len(v) == 0 {
@@ -38,15 +91,21 @@ func (v VGPUConfig) AssertValid() error {
 		if err != nil {
 			return fmt.Errorf("invalid format for '%v': %v", key, err)
 		}
+		if val == VGPUCountMax {
+			if len(v) > 1 {
+				return fmt.Errorf("count '%v' for '%v' cannot be combined with other vGPU types", vgpuCountMaxString, key)
+			}
+			return nil
+		}
 		if val <= 0 {
 			return fmt.Errorf("invalid count for '%v': %v", val, err)
 		}
-		if vgpuType.G > 0 {
+		if vgpuType.IsMIGBacked() {
 			if idx > 0 && !migBacked {
 				return fmt.Errorf("cannot mix time-sliced and MIG-backed vGPU devices on the same GPU")
 			}
 			migBacked = true
-		} else if vgpuType.G <= 0 {
+		} else if vgpuType.IsTimeSliced() {
 			if idx > 0 && migBacked {
 				return fmt.Errorf("cannot mix time-sliced and MIG-backed vGPU devices on the same GPU")
 			}
@@ -71,6 +130,65 @@ func (v VGPUConfig) Contains(vgpuType string) bool {
 	return v[vgpuType] > 0
 }
 
+// HasMaxCount checks if any of the vGPU types in the 'VGPUConfig' has a count of 'VGPUCountMax'.
+func (v VGPUConfig) HasMaxCount() bool {
+	for _, val := range v {
+		if val == VGPUCountMax {
+			return true
+		}
+	}
+	return false
+}
+
+// UnmarshalJSON unmarshals raw bytes into a 'VGPUConfig'.
+// Each count is either a number or the string "max".
+func (v *VGPUConfig) UnmarshalJSON(b []byte) error {
+	var raw map[string]json.RawMessage
+	err := json.Unmarshal(b, &raw)
+	if err != nil {
+		return err
+	}
+	if raw == nil {
+		return nil
+	}
+
+	result := make(VGPUConfig, len(raw))
+	for key, val := range raw {
+		var count int
+		err1 := json.Unmarshal(val, &count)
+		if err1 == nil {
+			result[key] = count
+			continue
+		}
+		var str string
+		err2 := json.Unmarshal(val, &str)
+		if err2 == nil && str == vgpuCountMaxString {
+			result[key] = VGPUCountMax
+			continue
+		}
+		return fmt.Errorf("invalid count for '%v': expected a number or \"%v\", got %s", key, vgpuCountMaxString, val)
+	}
+
+	*v = result
+	return nil
+}
+
+// MarshalJSON marshals a 'VGPUConfig' into raw bytes, writing a count of 'VGPUCountMax' as "max".
+func (v VGPUConfig) MarshalJSON() ([]byte, error) {
+	if v == nil {
+		return []byte("null"), nil
+	}
+	result := make(map[string]interface{}, len(v))
+	for key, val := range v {
+		if val == VGPUCountMax {
+			result[key] = vgpuCountMaxString
+			continue
+		}
+		result[key] = val
+	}
+	return json.Marshal(result)
+}
+
 // Equals checks if two 'VGPUConfig's are equal.
 // Equality is determined by comparing the vGPU types contained in each 'VGPUConfig'.
 func (v VGPUConfig) Equals(config VGPUConfig) bool {
@@ -87,3 +205,66 @@ func (v VGPUConfig) Equals(config VGPUConfig) bool {
 	}
 	return true
 }
+
+// VGPUConfigDiff describes the differences between two 'VGPUConfig's, type by type.
+// A vGPU type with a count of 0 is treated as not being part of a 'VGPUConfig'.
+type VGPUConfigDiff struct {
+	// Added holds the vGPU types only present in the new config, along with their counts.
+	Added map[string]int
+	// Removed holds the vGPU types only present in the old config, along with their counts.
+	Removed map[string]int
+	// Changed holds the vGPU types present in both configs with different counts, along with their new counts.
+	Changed map[string]int
+}
+
+// Diff compares a 'VGPUConfig' against an 'other' one, type by type.
+// Both configs are expected to have any 'VGPUCountMax' counts resolved.
+func (v VGPUConfig) Diff(other VGPUConfig) VGPUConfigDiff {
+	diff := VGPUConfigDiff{
+		Added:   make(map[string]int),
+		Removed: make(map[string]int),
+		Changed: make(map[string]int),
+	}
+	for vgpuType, count := range v {
+		if count > 0 && !other.Contains(vgpuType) {
+			diff.Removed[vgpuType] = count
+		}
+	}
+	for vgpuType, count := range other {
+		if count <= 0 {
+			continue
+		}
+		switch {
+		case !v.Contains(vgpuType):
+			diff.Added[vgpuType] = count
+		case v[vgpuType] != count:
+			diff.Changed[vgpuType] = count
+		}
+	}
+	return diff
+}
+
+// IsEmpty checks if a 'VGPUConfigDiff' contains no differences at all.
+func (d VGPUConfigDiff) IsEmpty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// String returns a 'VGPUConfigDiff' as a sorted, comma-separated list of
+// '+<type>=<count>', '-<type>=<count>' and '~<type>=<count>' entries.
+func (d VGPUConfigDiff) String() string {
+	if d.IsEmpty() {
+		return "no changes"
+	}
+
+	var entries []string
+	for prefix, m := range map[string]map[string]int{"+": d.Added, "-": d.Removed, "~": d.Changed} {
+		for vgpuType, count := range m {
+			entries = append(entries, fmt.Sprintf("%s%s=%d", prefix, vgpuType, count))
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i][1:] < entries[j][1:]
+	})
+
+	return strings.Join(entries, ", ")
+}
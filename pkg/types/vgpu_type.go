@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"regexp"
 	"strconv"
+	"strings"
 )
 
 const (
@@ -28,11 +29,17 @@ const (
 	// timeSlicedRegex represents the format for a time-sliced, vGPU type name.
 	// It embeds the GPU type, framebuffer size in GB, and a letter representing the 'series'.
 	// Note: The framebuffer size can be '0' to represent 512MB (i.e. M60-0Q).
-	timeSlicedRegex = "^(?P<GPU>[A-Z0-9]+(-([a-zA-Z]+))*)-(?P<GB>0|[1-9][0-9]*)(?P<S>A|B|C|Q)$"
+	timeSlicedRegex = "^(?P<GPU>[A-Z0-9]+(-([a-zA-Z]+))*)-(?P<GB>0|[1-9][0-9]*)(?P<S>A|B|C|D|Q)$"
 	// migBackedRegex represents the format for a MIG-backed, vGPU type name.
 	// In addition to embedding all of the fields from 'timeSlicedRegex', it also
 	// contains the number of GPU instances and any additional attributes (i.e. media extensions).
-	migBackedRegex = "^(?P<GPU>[A-Z0-9]+)-(?P<G>[1-9])-(?P<GB>0|[1-9][0-9]*)(?P<S>A|B|C|Q)(?P<ME>ME)?$"
+	migBackedRegex = "^(?P<GPU>[A-Z0-9]+)-(?P<G>[1-9])-(?P<GB>0|[1-9][0-9]*)(?P<S>A|B|C|D|Q)(?P<ME>ME)?$"
+)
+
+// The vGPU type name regexes are compiled once, rather than on every call to ParseVGPUType.
+var (
+	timeSlicedRe = regexp.MustCompile(timeSlicedRegex)
+	migBackedRe  = regexp.MustCompile(migBackedRegex)
 )
 
 // VGPUType represents a specific vGPU type.
@@ -58,13 +65,13 @@ func ParseVGPUType(s string) (*VGPUType, error) {
 		return nil, fmt.Errorf("empty vGPU type string")
 	}
 
-	captureGroups := parseRegex(timeSlicedRegex, s)
-	if len(captureGroups) == 0 {
-		captureGroups = parseRegex(migBackedRegex, s)
+	captureGroups, matched := parseRegex(timeSlicedRe, s)
+	if !matched {
+		captureGroups, matched = parseRegex(migBackedRe, s)
 	}
 
-	if len(captureGroups) == 0 {
-		return nil, fmt.Errorf("malformed vGPU type string '%s'", s)
+	if !matched {
+		return nil, fmt.Errorf("malformed vGPU type string '%s': expected <gpu>-<gb><series> or <gpu>-<g>-<gb><series>[ME]", s)
 	}
 
 	gpu = captureGroups["GPU"]
@@ -89,7 +96,7 @@ func ParseVGPUType(s string) (*VGPUType, error) {
 			return nil, fmt.Errorf("malformed number for GPU instances '%s'", gStr)
 		}
 		mediaExtension, ok := captureGroups["ME"]
-		if ok {
+		if ok && mediaExtension != "" {
 			attr = append(attr, mediaExtension)
 		}
 	}
@@ -104,16 +111,79 @@ func ParseVGPUType(s string) (*VGPUType, error) {
 	return v, nil
 }
 
-func parseRegex(re, s string) map[string]string {
-	var r = regexp.MustCompile(re)
+// MDEVTypeName returns the mdev type name from the contents of the 'name' file of an mdev type,
+// which is in the format "[NVIDIA|GRID] <vGPU type>". Like go-nvlib's nvmdev, only the first word
+// is stripped, so that the result matches the mdev type names used by its ParentDevice methods,
+// e.g. "RTX Pro 6000 Blackwell DC-48C" for "NVIDIA RTX Pro 6000 Blackwell DC-48C".
+func MDEVTypeName(rawName string) string {
+	nameSplit := strings.SplitN(strings.TrimSpace(rawName), " ", 2)
+	return nameSplit[len(nameSplit)-1]
+}
+
+// ParseVGPUTypeName converts the contents of the 'name' file of an mdev type into an object,
+// e.g. "NVIDIA A100-4C" or "GRID A100-4C". Product names that are not a valid vGPU type once the
+// first word is stripped, e.g. "NVIDIA RTX Pro 6000 Blackwell DC-48C", are rejected.
+func ParseVGPUTypeName(rawName string) (*VGPUType, error) {
+	v, err := ParseVGPUType(MDEVTypeName(rawName))
+	if err != nil {
+		return nil, fmt.Errorf("malformed vGPU type name '%s': %v", strings.TrimSpace(rawName), err)
+	}
+	return v, nil
+}
+
+// String returns the vGPU type name represented by 'v'. It is the inverse of ParseVGPUType.
+func (v VGPUType) String() string {
+	if v.IsMIGBacked() {
+		return fmt.Sprintf("%s-%d-%d%c%s", v.GPU, v.G, v.GB, v.S, strings.Join(v.Attr, ""))
+	}
+	return fmt.Sprintf("%s-%d%c", v.GPU, v.GB, v.S)
+}
+
+// IsMIGBacked returns whether this vGPU type is backed by a MIG device, i.e. has a number of GPU instances.
+func (v VGPUType) IsMIGBacked() bool {
+	return v.G > 0
+}
+
+// IsTimeSliced returns whether this vGPU type is time-sliced, i.e. not backed by a MIG device.
+func (v VGPUType) IsTimeSliced() bool {
+	return v.G == 0
+}
+
+// InstancesPerGB returns the number of vGPU instances of this type that fit in a single GB of framebuffer.
+// A framebuffer size of '0' represents 512MB, so such types fit 2 instances per GB.
+func (v VGPUType) InstancesPerGB() float64 {
+	return 1.0 / v.MemoryGB()
+}
+
+// MemoryMB returns the framebuffer size of this vGPU type in MB.
+// A framebuffer size of '0' represents 512MB.
+func (v VGPUType) MemoryMB() int {
+	if v.GB == 0 {
+		return 512
+	}
+	return v.GB * 1024
+}
+
+// MemoryGB returns the framebuffer size of this vGPU type in GB.
+// A framebuffer size of '0' represents 512MB, i.e. 0.5GB.
+func (v VGPUType) MemoryGB() float64 {
+	return float64(v.MemoryMB()) / 1024
+}
+
+// parseRegex matches 's' against 'r' and returns its named capture groups.
+// The returned bool is false if 's' does not match 'r' at all.
+func parseRegex(r *regexp.Regexp, s string) (map[string]string, bool) {
 	match := r.FindStringSubmatch(s)
+	if match == nil {
+		return nil, false
+	}
 
 	captureGroups := make(map[string]string)
 	for i, name := range r.SubexpNames() {
-		if i > 0 && i <= len(match) {
+		if i > 0 && name != "" {
 			captureGroups[name] = match[i]
 		}
 	}
 
-	return captureGroups
+	return captureGroups, true
 }
@@ -0,0 +1,128 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package vgpu
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/NVIDIA/go-nvlib/pkg/nvmdev"
+
+	"github.com/NVIDIA/vgpu-device-manager/pkg/types"
+)
+
+// GPUCapacity describes the vGPU capacity of a single GPU on the node
+type GPUCapacity struct {
+	GPU        int    `json:"gpu"`
+	PCIAddress string `json:"pciAddress"`
+	// ParentDevices is the number of mdev 'parent' devices backed by the GPU.
+	// With SR-IOV, this is the number of virtual functions.
+	ParentDevices int `json:"parentDevices"`
+	// ActiveDevices is the number of vGPU devices that currently exist on the GPU.
+	ActiveDevices int `json:"activeDevices"`
+	// AvailableInstances holds the number of vGPU devices of each supported type that can still be created.
+	AvailableInstances map[string]int `json:"availableInstances"`
+}
+
+// GetVGPUCapacityReport returns the 'GPUCapacity' of every GPU on the node, ordered by GPU index.
+// GPUs that do not support vGPU are included with no parent devices.
+func (m *nvlibVGPUConfigManager) GetVGPUCapacityReport() ([]GPUCapacity, error) {
+	gpus, err := m.gpus.GetGPUs()
+	if err != nil {
+		return nil, fmt.Errorf("error enumerating GPUs: %v", err)
+	}
+
+	allParents, err := m.nvlib.Nvmdev.GetAllParentDevices()
+	if err != nil {
+		return nil, fmt.Errorf("error getting all parent devices: %v", err)
+	}
+
+	vgpuDevs, err := m.nvlib.Nvmdev.GetAllDevices()
+	if err != nil {
+		return nil, fmt.Errorf("error getting all vGPU devices: %v", err)
+	}
+
+	var report []GPUCapacity
+	for i, gpu := range gpus {
+		capacity := GPUCapacity{
+			GPU:                i,
+			PCIAddress:         gpu.Address,
+			AvailableInstances: make(map[string]int),
+		}
+
+		for _, p := range allParents {
+			if p.GetPhysicalFunction().Address != gpu.Address {
+				continue
+			}
+			capacity.ParentDevices++
+
+			mdevTypes, err := getSupportedMDEVTypes(p)
+			if err != nil {
+				return nil, fmt.Errorf("error getting supported vGPU types for GPU %d: %v", i, err)
+			}
+			for _, mdevType := range mdevTypes {
+				available, err := p.GetAvailableMDEVInstances(mdevType)
+				if err != nil {
+					return nil, fmt.Errorf("error getting available vGPU instances for GPU %d: %v", i, err)
+				}
+				if available < 0 {
+					continue
+				}
+				capacity.AvailableInstances[mdevType] += available
+			}
+		}
+
+		for _, vgpuDev := range vgpuDevs {
+			if vgpuDev.GetPhysicalFunction().Address == gpu.Address {
+				capacity.ActiveDevices++
+			}
+		}
+
+		report = append(report, capacity)
+	}
+
+	return report, nil
+}
+
+// assertVGPUCapacity checks that every vGPU type in 'config' has room for the number of devices requested on a set
+// of 'parent' devices backed by the same GPU, i.e. that the instances still available across all parents plus the
+// existing 'devices' of that type (which are deleted before the config is applied) are enough. Each type is checked on
+// its own. Contention between types is left to fail when the devices are created.
+func assertVGPUCapacity(parents []*nvmdev.ParentDevice, devices []*nvmdev.Device, config types.VGPUConfig) error {
+	var keys []string
+	for key := range config {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		val := config[key]
+		if val == types.VGPUCountMax {
+			continue
+		}
+
+		total, err := getTotalMDEVInstances(parents, devices, key)
+		if err != nil {
+			return fmt.Errorf("error getting vGPU capacity: %v", err)
+		}
+		if val > total {
+			return fmt.Errorf("%w: %d %s vGPU devices requested, but only %d available", ErrInsufficientCapacity, val, key, total)
+		}
+	}
+
+	return nil
+}
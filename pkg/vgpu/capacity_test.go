@@ -0,0 +1,192 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package vgpu
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/NVIDIA/go-nvlib/pkg/nvmdev"
+	"github.com/stretchr/testify/require"
+
+	"github.com/NVIDIA/vgpu-device-manager/pkg/types"
+)
+
+// The directories of the mdev types created for each parent by 'nvmdev.MockNvmdev.AddMockA100Parent'
+var mockMDEVTypeDirs = map[string]string{
+	"A100-4C":    "nvidia-500",
+	"A100-5C":    "nvidia-501",
+	"A100-1-5C":  "nvidia-507",
+	"A100-2-10C": "nvidia-508",
+	"A100-3-20C": "nvidia-509",
+	"A100-4-20C": "nvidia-510",
+}
+
+// newMockNvmdev creates a mock parent device at each of 'addresses'. On each of them, the number of available
+// instances of every mdev type in 'available' is set accordingly (and to 0 for all other types in mockMDEVTypeDirs),
+// and the description of every type sets 'max_instance' if 'maxInstances' is not negative. Each type also gets an
+// empty 'create' file, so that creating devices succeeds.
+func newMockNvmdev(t *testing.T, addresses []string, available map[string]int, maxInstances int) *nvmdev.MockNvmdev {
+	mock, err := nvmdev.NewMock()
+	require.NoError(t, err)
+	t.Cleanup(mock.Cleanup)
+
+	for _, address := range addresses {
+		require.NoError(t, mock.AddMockA100Parent(address, 0))
+	}
+
+	parents, err := mock.GetAllParentDevices()
+	require.NoError(t, err)
+	require.Len(t, parents, len(addresses))
+
+	description := "num_heads=4, frl_config=60, framebuffer=4096M, max_resolution=5120x2880"
+	if maxInstances >= 0 {
+		description += ", max_instance=" + strconv.Itoa(maxInstances)
+	}
+	for _, p := range parents {
+		for mdevType, dir := range mockMDEVTypeDirs {
+			typeDir := filepath.Join(p.Path, "mdev_supported_types", dir)
+			require.NoError(t, os.WriteFile(filepath.Join(typeDir, "available_instances"), []byte(strconv.Itoa(available[mdevType])), 0644))
+			require.NoError(t, os.WriteFile(filepath.Join(typeDir, "description"), []byte(description), 0644))
+			require.NoError(t, os.WriteFile(filepath.Join(typeDir, "create"), nil, 0644))
+		}
+	}
+
+	return mock
+}
+
+// newMockParents creates 'count' mock parent devices as described by newMockNvmdev.
+func newMockParents(t *testing.T, count int, available map[string]int, maxInstances int) []*nvmdev.ParentDevice {
+	var addresses []string
+	for i := 0; i < count; i++ {
+		addresses = append(addresses, "0000:3b:00."+strconv.Itoa(i))
+	}
+
+	parents, err := newMockNvmdev(t, addresses, available, maxInstances).GetAllParentDevices()
+	require.NoError(t, err)
+	return parents
+}
+
+func TestAssertVGPUCapacity(t *testing.T) {
+	testCases := []struct {
+		description string
+		available   map[string]int
+		devices     map[string]int
+		config      types.VGPUConfig
+		expectedErr error
+	}{
+		{
+			"Config fits",
+			map[string]int{"A100-4C": 2},
+			nil,
+			types.VGPUConfig{"A100-4C": 6},
+			nil,
+		},
+		{
+			"3 parents with capacity 2 each cannot hold 7 devices",
+			map[string]int{"A100-4C": 2},
+			nil,
+			types.VGPUConfig{"A100-4C": 7},
+			ErrInsufficientCapacity,
+		},
+		{
+			"Existing devices of the same type count as capacity",
+			map[string]int{"A100-4C": 1},
+			map[string]int{"A100-4C": 1},
+			types.VGPUConfig{"A100-4C": 6},
+			nil,
+		},
+		{
+			"Existing devices of another type do not count as capacity",
+			map[string]int{"A100-4C": 1},
+			map[string]int{"A100-5C": 1},
+			types.VGPUConfig{"A100-4C": 4},
+			ErrInsufficientCapacity,
+		},
+		{
+			"Type without capacity",
+			map[string]int{"A100-4C": 2},
+			nil,
+			types.VGPUConfig{"A100-5C": 1},
+			ErrInsufficientCapacity,
+		},
+		{
+			"Heterogeneous MIG-backed types are checked on their own",
+			map[string]int{"A100-4-20C": 1, "A100-3-20C": 1},
+			nil,
+			types.VGPUConfig{"A100-4-20C": 1, "A100-3-20C": 1},
+			nil,
+		},
+		{
+			"Heterogeneous MIG-backed types with several devices of one type",
+			map[string]int{"A100-2-10C": 1, "A100-1-5C": 1},
+			nil,
+			types.VGPUConfig{"A100-2-10C": 3, "A100-1-5C": 1},
+			nil,
+		},
+		{
+			"Max count is not checked",
+			nil,
+			nil,
+			types.VGPUConfig{"A100-4C": types.VGPUCountMax},
+			nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			parents := newMockParents(t, 3, tc.available, -1)
+
+			var devices []*nvmdev.Device
+			for mdevType, count := range tc.devices {
+				for _, p := range parents {
+					for i := 0; i < count; i++ {
+						devices = append(devices, &nvmdev.Device{MDEVType: mdevType, Parent: p})
+					}
+				}
+			}
+
+			err := assertVGPUCapacity(parents, devices, tc.config)
+			if tc.expectedErr == nil {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorIs(t, err, tc.expectedErr)
+		})
+	}
+}
+
+func TestGetVGPUCapacityReport(t *testing.T) {
+	mock, parents, m := newMockManager(t, map[string]int{"A100-4C": 2, "A100-5C": 1})
+	addMockDevice(t, mock, parents[1], "b1914f0a-7c4f-4d8f-8c5b-8f5b9d1c8c01", "A100-4C")
+
+	report, err := m.GetVGPUCapacityReport()
+	require.NoError(t, err)
+	require.Len(t, report, 2)
+
+	for i, capacity := range report {
+		require.Equal(t, i, capacity.GPU)
+		require.Equal(t, parents[i].Address, capacity.PCIAddress)
+		require.Equal(t, 1, capacity.ParentDevices)
+		require.Equal(t, 2, capacity.AvailableInstances["A100-4C"])
+		require.Equal(t, 1, capacity.AvailableInstances["A100-5C"])
+	}
+	require.Equal(t, 0, report[0].ActiveDevices)
+	require.Equal(t, 1, report[1].ActiveDevices)
+}
@@ -17,10 +17,14 @@
 package vgpu
 
 import (
+	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/NVIDIA/go-nvlib/pkg/nvmdev"
+	"github.com/NVIDIA/go-nvlib/pkg/nvpci"
 	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
 
 	"github.com/NVIDIA/vgpu-device-manager/internal/nvlib"
 	"github.com/NVIDIA/vgpu-device-manager/pkg/types"
@@ -31,22 +35,87 @@ type Manager interface {
 	GetVGPUConfig(gpu int) (types.VGPUConfig, error)
 	SetVGPUConfig(gpu int, config types.VGPUConfig) error
 	ClearVGPUConfig(gpu int) error
+	AssertVGPUConfigSupported(gpu int, config types.VGPUConfig) error
+	GetMaxVGPUInstances(gpu int, vgpuType string) (int, error)
+	GetTotalVGPUInstances(gpu int, vgpuType string) (int, error)
+	ResolveVGPUConfig(gpu int, config types.VGPUConfig) (types.VGPUConfig, error)
+	GetVGPUCapacityReport() ([]GPUCapacity, error)
+}
+
+// GPULookup represents the lookup of the GPUs on the node, either all of them or one by its index
+type GPULookup interface {
+	GetGPUs() ([]*nvpci.NvidiaPCIDevice, error)
+	GetGPUByIndex(int) (*nvpci.NvidiaPCIDevice, error)
+}
+
+// ErrInsufficientCapacity is returned when a GPU cannot hold the number of vGPU devices of a type requested in a config
+var ErrInsufficientCapacity = errors.New("insufficient vGPU capacity")
+
+// DeviceDeletionError is returned when one or more vGPU devices on a GPU could not be deleted,
+// i.e. because they are in use by a VM. It holds one error per device that was not deleted.
+type DeviceDeletionError struct {
+	GPU    int
+	Errors []error
+}
+
+var _ error = (*DeviceDeletionError)(nil)
+
+// Error returns a 'DeviceDeletionError' as a string listing every device that could not be deleted
+func (e *DeviceDeletionError) Error() string {
+	var errs []string
+	for _, err := range e.Errors {
+		errs = append(errs, err.Error())
+	}
+	return fmt.Sprintf("unable to delete %d vGPU device(s) on GPU %d: %s", len(e.Errors), e.GPU, strings.Join(errs, "; "))
+}
+
+// Unwrap returns the errors for the individual devices that could not be deleted
+func (e *DeviceDeletionError) Unwrap() []error {
+	return e.Errors
 }
 
 type nvlibVGPUConfigManager struct {
 	nvlib nvlib.Interface
+	gpus  GPULookup
 }
 
 var _ Manager = (*nvlibVGPUConfigManager)(nil)
+var _ GPULookup = (nvpci.Interface)(nil)
+
+// Option is a function for passing options to 'NewNvlibVGPUConfigManager'.
+type Option func(*nvlibVGPUConfigManager)
+
+// WithGPULookup provides an 'Option' that sets the 'GPULookup' used to find the GPUs on the node.
+// It defaults to go-nvlib's nvpci.
+func WithGPULookup(gpus GPULookup) Option {
+	return func(m *nvlibVGPUConfigManager) {
+		m.gpus = gpus
+	}
+}
+
+// WithNvmdev provides an 'Option' that sets the nvmdev interface used to find parent and vGPU devices.
+func WithNvmdev(lib nvmdev.Interface) Option {
+	return func(m *nvlibVGPUConfigManager) {
+		m.nvlib.Nvmdev = lib
+	}
+}
 
 // NewNvlibVGPUConfigManager returns a new vGPU Config Manager which uses go-nvlib when creating / deleting vGPU devices
-func NewNvlibVGPUConfigManager() Manager {
-	return &nvlibVGPUConfigManager{nvlib.New()}
+func NewNvlibVGPUConfigManager(opts ...Option) Manager {
+	lib := nvlib.New()
+	m := &nvlibVGPUConfigManager{
+		nvlib: lib,
+		gpus:  lib.Nvpci,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
 }
 
 // GetVGPUConfig gets the 'VGPUConfig' currently applied to a GPU at a particular index
 func (m *nvlibVGPUConfigManager) GetVGPUConfig(gpu int) (types.VGPUConfig, error) {
-	device, err := m.nvlib.Nvpci.GetGPUByIndex(gpu)
+	device, err := m.gpus.GetGPUByIndex(gpu)
 	if err != nil {
 		return nil, fmt.Errorf("error getting device at index '%d': %v", gpu, err)
 	}
@@ -69,40 +138,37 @@ func (m *nvlibVGPUConfigManager) GetVGPUConfig(gpu int) (types.VGPUConfig, error
 
 // SetVGPUConfig applies the selected `VGPUConfig` to a GPU at a particular index if it is not already applied
 func (m *nvlibVGPUConfigManager) SetVGPUConfig(gpu int, config types.VGPUConfig) error {
-	device, err := m.nvlib.Nvpci.GetGPUByIndex(gpu)
+	device, parents, err := m.getParentDevices(gpu)
 	if err != nil {
-		return fmt.Errorf("error getting device at index '%d': %v", gpu, err)
+		return err
 	}
 
-	allParents, err := m.nvlib.Nvmdev.GetAllParentDevices()
+	// Before deleting any existing vGPU devices, ensure all vGPU types specified in
+	// the config are supported for the GPU we are applying the configuration to.
+	err = assertVGPUConfigSupported(gpu, device, parents, config)
 	if err != nil {
-		return fmt.Errorf("error getting all parent devices: %v", err)
+		return err
 	}
 
-	// Filter for 'parent' devices that are backed by the physical function
-	parents := []*nvmdev.ParentDevice{}
-	for _, p := range allParents {
-		pf := p.GetPhysicalFunction()
-		if pf.Address == device.Address {
-			parents = append(parents, p)
-		}
+	// Ensure the GPU has enough capacity for each vGPU type in the config before deleting
+	// any existing devices, so that a type that cannot fit does not leave the GPU blank.
+	vgpuDevs, err := m.nvlib.Nvmdev.GetAllDevices()
+	if err != nil {
+		return fmt.Errorf("error getting all vGPU devices: %v", err)
 	}
-
-	if len(parents) == 0 {
-		return fmt.Errorf("no parent devices found for GPU at index '%d'", gpu)
+	err = assertVGPUCapacity(parents, vgpuDevs, config)
+	if err != nil {
+		return fmt.Errorf("%w on GPU (index=%d, address=%s)", err, gpu, device.Address)
 	}
 
-	// Before deleting any existing vGPU devices, ensure all vGPU types specified in
-	// the config are supported for the GPU we are applying the configuration to.
-	for key := range config {
-		if !parents[0].IsMDEVTypeSupported(key) {
-			return fmt.Errorf("vGPU type %s is not supported on GPU (index=%d, address=%s)", key, gpu, device.Address)
-		}
+	err = m.ClearVGPUConfig(gpu)
+	if err != nil {
+		return fmt.Errorf("error clearing VGPUConfig: %w", err)
 	}
 
-	err = m.ClearVGPUConfig(gpu)
+	config, err = resolveAvailableVGPUInstances(parents, config)
 	if err != nil {
-		return fmt.Errorf("error clearing VGPUConfig: %v", err)
+		return err
 	}
 
 	for key, val := range config {
@@ -127,11 +193,19 @@ func (m *nvlibVGPUConfigManager) SetVGPUConfig(gpu int, config types.VGPUConfig)
 			}
 
 			numToCreate := min(remainingToCreate, available)
+			logger := log.WithFields(log.Fields{
+				"gpu_index":   gpu,
+				"vgpu_type":   key,
+				"pci_address": parent.Address,
+			})
+			logger.WithField("count", numToCreate).Info("Creating vGPU devices")
 			for i := 0; i < numToCreate; i++ {
-				err = parent.CreateMDEVDevice(key, uuid.New().String())
+				id := uuid.New().String()
+				err = parent.CreateMDEVDevice(key, id)
 				if err != nil {
 					return fmt.Errorf("unable to create %s vGPU device on parent device %s: %v", key, parent.Address, err)
 				}
+				logger.WithField("uuid", id).Info("Created vGPU device")
 			}
 			remainingToCreate -= numToCreate
 		}
@@ -143,9 +217,10 @@ func (m *nvlibVGPUConfigManager) SetVGPUConfig(gpu int, config types.VGPUConfig)
 	return nil
 }
 
-// ClearVGPUConfig clears the 'VGPUConfig' for a GPU at a particular index by deleting all vGPU devices associated with it
+// ClearVGPUConfig clears the 'VGPUConfig' for a GPU at a particular index by deleting all vGPU devices associated with it.
+// Every device is attempted, even if some of them fail to be deleted; those are reported in a 'DeviceDeletionError'.
 func (m *nvlibVGPUConfigManager) ClearVGPUConfig(gpu int) error {
-	device, err := m.nvlib.Nvpci.GetGPUByIndex(gpu)
+	device, err := m.gpus.GetGPUByIndex(gpu)
 	if err != nil {
 		return fmt.Errorf("error getting device at index '%d': %v", gpu, err)
 	}
@@ -155,16 +230,169 @@ func (m *nvlibVGPUConfigManager) ClearVGPUConfig(gpu int) error {
 		return fmt.Errorf("error getting all vGPU devices: %v", err)
 	}
 
+	var errs []error
 	for _, vgpuDev := range vgpuDevs {
 		pf := vgpuDev.GetPhysicalFunction()
 		if device.Address == pf.Address {
 			err = vgpuDev.Delete()
 			if err != nil {
-				return fmt.Errorf("error deleting %s vGPU device with id %s: %v", vgpuDev.MDEVType, vgpuDev.UUID, err)
+				errs = append(errs, fmt.Errorf("error deleting %s vGPU device with id %s: %w", vgpuDev.MDEVType, vgpuDev.UUID, err))
 			}
 		}
 	}
 
+	if len(errs) > 0 {
+		return &DeviceDeletionError{GPU: gpu, Errors: errs}
+	}
+
+	return nil
+}
+
+// AssertVGPUConfigSupported checks that all vGPU types in a 'VGPUConfig' are supported by the GPU at a particular index,
+// and that the requested number of each type does not exceed the maximum the GPU can hold
+func (m *nvlibVGPUConfigManager) AssertVGPUConfigSupported(gpu int, config types.VGPUConfig) error {
+	device, parents, err := m.getParentDevices(gpu)
+	if err != nil {
+		return err
+	}
+	return assertVGPUConfigSupported(gpu, device, parents, config)
+}
+
+// getParentDevices returns the GPU at a particular index along with all 'parent' devices backed by its physical function
+func (m *nvlibVGPUConfigManager) getParentDevices(gpu int) (*nvpci.NvidiaPCIDevice, []*nvmdev.ParentDevice, error) {
+	device, err := m.gpus.GetGPUByIndex(gpu)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error getting device at index '%d': %v", gpu, err)
+	}
+
+	allParents, err := m.nvlib.Nvmdev.GetAllParentDevices()
+	if err != nil {
+		return nil, nil, fmt.Errorf("error getting all parent devices: %v", err)
+	}
+
+	// Filter for 'parent' devices that are backed by the physical function
+	parents := []*nvmdev.ParentDevice{}
+	for _, p := range allParents {
+		pf := p.GetPhysicalFunction()
+		if pf.Address == device.Address {
+			parents = append(parents, p)
+		}
+	}
+
+	if len(parents) == 0 {
+		return nil, nil, fmt.Errorf("no parent devices found for GPU at index '%d'", gpu)
+	}
+
+	return device, parents, nil
+}
+
+// GetMaxVGPUInstances returns the maximum number of vGPU devices of a particular type that can be created on
+// the GPU at a particular index. It returns -1 if the maximum cannot be determined.
+func (m *nvlibVGPUConfigManager) GetMaxVGPUInstances(gpu int, vgpuType string) (int, error) {
+	_, parents, err := m.getParentDevices(gpu)
+	if err != nil {
+		return -1, err
+	}
+	return getMaxMDEVInstances(parents, vgpuType)
+}
+
+// GetTotalVGPUInstances returns the number of vGPU devices of a particular type that can still be created on the
+// GPU at a particular index plus the number of devices of that type that already exist on it
+func (m *nvlibVGPUConfigManager) GetTotalVGPUInstances(gpu int, vgpuType string) (int, error) {
+	_, parents, err := m.getParentDevices(gpu)
+	if err != nil {
+		return -1, err
+	}
+
+	vgpuDevs, err := m.nvlib.Nvmdev.GetAllDevices()
+	if err != nil {
+		return -1, fmt.Errorf("error getting all vGPU devices: %v", err)
+	}
+
+	return getTotalMDEVInstances(parents, vgpuDevs, vgpuType)
+}
+
+// ResolveVGPUConfig returns a copy of 'config' with every 'VGPUCountMax' count replaced by the
+// maximum number of vGPU devices of that type that can be created on the GPU at a particular index.
+// Configs without a 'VGPUCountMax' count are returned as-is.
+func (m *nvlibVGPUConfigManager) ResolveVGPUConfig(gpu int, config types.VGPUConfig) (types.VGPUConfig, error) {
+	if !config.HasMaxCount() {
+		return config, nil
+	}
+
+	_, parents, err := m.getParentDevices(gpu)
+	if err != nil {
+		return nil, err
+	}
+
+	resolved := make(types.VGPUConfig, len(config))
+	for key, val := range config {
+		if val != types.VGPUCountMax {
+			resolved[key] = val
+			continue
+		}
+		maxInstances, err := getMaxMDEVInstances(parents, key)
+		if err != nil {
+			return nil, fmt.Errorf("error getting maximum vGPU instances: %v", err)
+		}
+		if maxInstances < 0 {
+			// Without a 'max_instance' attribute, fall back to the instances still
+			// available plus the devices of this type that already exist. If devices
+			// of other types exist, the GPU does not match the config either way.
+			maxInstances, err = m.GetTotalVGPUInstances(gpu, key)
+			if err != nil {
+				return nil, fmt.Errorf("error getting total vGPU instances: %v", err)
+			}
+		}
+		resolved[key] = maxInstances
+	}
+
+	return resolved, nil
+}
+
+// resolveAvailableVGPUInstances returns a copy of 'config' with every 'VGPUCountMax' count replaced by
+// the number of vGPU devices of that type that can still be created across all 'parents'.
+func resolveAvailableVGPUInstances(parents []*nvmdev.ParentDevice, config types.VGPUConfig) (types.VGPUConfig, error) {
+	if !config.HasMaxCount() {
+		return config, nil
+	}
+
+	resolved := make(types.VGPUConfig, len(config))
+	for key, val := range config {
+		if val != types.VGPUCountMax {
+			resolved[key] = val
+			continue
+		}
+		total, err := getAvailableMDEVInstances(parents, key)
+		if err != nil {
+			return nil, err
+		}
+		if total == 0 {
+			return nil, fmt.Errorf("no %s vGPU devices can be created on the GPU", key)
+		}
+		log.WithFields(log.Fields{"vgpu_type": key, "count": total}).Info("Resolved 'max' vGPU device count")
+		resolved[key] = total
+	}
+
+	return resolved, nil
+}
+
+func assertVGPUConfigSupported(gpu int, device *nvpci.NvidiaPCIDevice, parents []*nvmdev.ParentDevice, config types.VGPUConfig) error {
+	for key, val := range config {
+		if !parents[0].IsMDEVTypeSupported(key) {
+			return fmt.Errorf("vGPU type %s is not supported on GPU (index=%d, address=%s)", key, gpu, device.Address)
+		}
+		maxInstances, err := getMaxMDEVInstances(parents, key)
+		if err != nil {
+			return fmt.Errorf("error getting maximum vGPU instances: %v", err)
+		}
+		if val == types.VGPUCountMax {
+			continue
+		}
+		if maxInstances >= 0 && val > maxInstances {
+			return fmt.Errorf("%d %s vGPU devices exceeds the maximum of %d on GPU (index=%d, address=%s)", val, key, maxInstances, gpu, device.Address)
+		}
+	}
 	return nil
 }
 
@@ -0,0 +1,268 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package vgpu
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/NVIDIA/go-nvlib/pkg/nvmdev"
+	"github.com/NVIDIA/go-nvlib/pkg/nvpci"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/NVIDIA/vgpu-device-manager/pkg/types"
+)
+
+// fakeGPULookup is a 'GPULookup' for a fixed list of GPUs, indexed by their position
+type fakeGPULookup []*nvpci.NvidiaPCIDevice
+
+func (f fakeGPULookup) GetGPUs() ([]*nvpci.NvidiaPCIDevice, error) {
+	return f, nil
+}
+
+func (f fakeGPULookup) GetGPUByIndex(i int) (*nvpci.NvidiaPCIDevice, error) {
+	if i < 0 || i >= len(f) {
+		return nil, fmt.Errorf("no GPU at index %d", i)
+	}
+	return f[i], nil
+}
+
+func TestGetParentDevices(t *testing.T) {
+	mock, err := nvmdev.NewMock()
+	require.NoError(t, err)
+	defer mock.Cleanup()
+
+	require.NoError(t, mock.AddMockA100Parent("0000:3b:00.0", 0))
+	require.NoError(t, mock.AddMockA100Parent("0000:86:00.0", 1))
+
+	gpus := fakeGPULookup{
+		{Address: "0000:3b:00.0"},
+		{Address: "0000:86:00.0"},
+		{Address: "0000:af:00.0"},
+	}
+	m := NewNvlibVGPUConfigManager(WithGPULookup(gpus), WithNvmdev(mock)).(*nvlibVGPUConfigManager)
+
+	testCases := []struct {
+		description string
+		gpu         int
+		parents     []string
+		expectedErr bool
+	}{
+		{
+			"First GPU",
+			0,
+			[]string{"0000:3b:00.0"},
+			false,
+		},
+		{
+			"Second GPU",
+			1,
+			[]string{"0000:86:00.0"},
+			false,
+		},
+		{
+			"GPU without parent devices",
+			2,
+			nil,
+			true,
+		},
+		{
+			"Unknown GPU index",
+			3,
+			nil,
+			true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			device, parents, err := m.getParentDevices(tc.gpu)
+			if tc.expectedErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, gpus[tc.gpu], device)
+
+			var addresses []string
+			for _, p := range parents {
+				addresses = append(addresses, p.Address)
+			}
+			require.Equal(t, tc.parents, addresses)
+		})
+	}
+}
+
+// addMockDevice creates a mock vGPU device of 'mdevType' on 'parent', with an empty 'remove' file so that deleting it
+// succeeds. It returns the path to the 'remove' file.
+func addMockDevice(t *testing.T, mock *nvmdev.MockNvmdev, parent *nvmdev.ParentDevice, id string, mdevType string) string {
+	require.NoError(t, mock.AddMockA100Mdev(id, mdevType, mockMDEVTypeDirs[mdevType], parent.Path))
+	remove := filepath.Join(parent.Path, id, "remove")
+	require.NoError(t, os.WriteFile(remove, nil, 0644))
+	return remove
+}
+
+// newMockManager returns a manager for two mock GPUs, each with a single parent device, along with the parent devices.
+func newMockManager(t *testing.T, available map[string]int) (*nvmdev.MockNvmdev, []*nvmdev.ParentDevice, Manager) {
+	addresses := []string{"0000:3b:00.0", "0000:86:00.0"}
+	mock := newMockNvmdev(t, addresses, available, -1)
+
+	allParents, err := mock.GetAllParentDevices()
+	require.NoError(t, err)
+
+	var gpus fakeGPULookup
+	var parents []*nvmdev.ParentDevice
+	for _, address := range addresses {
+		gpus = append(gpus, &nvpci.NvidiaPCIDevice{Address: address})
+		for _, p := range allParents {
+			if p.Address == address {
+				parents = append(parents, p)
+			}
+		}
+	}
+	require.Len(t, parents, len(addresses))
+
+	return mock, parents, NewNvlibVGPUConfigManager(WithGPULookup(gpus), WithNvmdev(mock))
+}
+
+func TestGetVGPUConfig(t *testing.T) {
+	mock, parents, m := newMockManager(t, nil)
+	addMockDevice(t, mock, parents[0], "b1914f0a-7c4f-4d8f-8c5b-8f5b9d1c8c01", "A100-4C")
+	addMockDevice(t, mock, parents[0], "b1914f0a-7c4f-4d8f-8c5b-8f5b9d1c8c02", "A100-4C")
+	addMockDevice(t, mock, parents[1], "b1914f0a-7c4f-4d8f-8c5b-8f5b9d1c8c03", "A100-5C")
+
+	config, err := m.GetVGPUConfig(0)
+	require.NoError(t, err)
+	require.Equal(t, types.VGPUConfig{"A100-4C": 2}, config)
+
+	config, err = m.GetVGPUConfig(1)
+	require.NoError(t, err)
+	require.Equal(t, types.VGPUConfig{"A100-5C": 1}, config)
+
+	_, err = m.GetVGPUConfig(2)
+	require.Error(t, err)
+}
+
+func TestClearVGPUConfig(t *testing.T) {
+	mock, parents, m := newMockManager(t, nil)
+	removeGPU0 := addMockDevice(t, mock, parents[0], "b1914f0a-7c4f-4d8f-8c5b-8f5b9d1c8c01", "A100-4C")
+	removeGPU1 := addMockDevice(t, mock, parents[1], "b1914f0a-7c4f-4d8f-8c5b-8f5b9d1c8c02", "A100-4C")
+
+	require.NoError(t, m.ClearVGPUConfig(0))
+	requireFileContent(t, "1", removeGPU0)
+	requireFileContent(t, "", removeGPU1)
+
+	require.Error(t, m.ClearVGPUConfig(2))
+}
+
+func TestClearVGPUConfigDeletionError(t *testing.T) {
+	mock, parents, m := newMockManager(t, nil)
+	// Without a 'remove' file, deleting the device fails
+	require.NoError(t, mock.AddMockA100Mdev("b1914f0a-7c4f-4d8f-8c5b-8f5b9d1c8c01", "A100-4C", mockMDEVTypeDirs["A100-4C"], parents[0].Path))
+
+	err := m.ClearVGPUConfig(0)
+	var deletionErr *DeviceDeletionError
+	require.ErrorAs(t, err, &deletionErr)
+	require.Equal(t, 0, deletionErr.GPU)
+	require.Len(t, deletionErr.Errors, 1)
+}
+
+func TestSetVGPUConfig(t *testing.T) {
+	testCases := []struct {
+		description string
+		config      types.VGPUConfig
+		// Whether the existing device is expected to be deleted
+		cleared bool
+		// The mdev types a device is expected to be created for
+		created     []string
+		expectedErr bool
+	}{
+		{
+			"Config is applied",
+			types.VGPUConfig{"A100-4C": 1},
+			true,
+			[]string{"A100-4C"},
+			false,
+		},
+		{
+			"Max count is applied",
+			types.VGPUConfig{"A100-4C": types.VGPUCountMax},
+			true,
+			[]string{"A100-4C"},
+			false,
+		},
+		{
+			"Unsupported type leaves the GPU untouched",
+			types.VGPUConfig{"T4-1Q": 1},
+			false,
+			nil,
+			true,
+		},
+		{
+			"Insufficient capacity leaves the GPU untouched",
+			types.VGPUConfig{"A100-4C": 2},
+			false,
+			nil,
+			true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			mock, parents, m := newMockManager(t, map[string]int{"A100-4C": 1})
+			remove := addMockDevice(t, mock, parents[0], "b1914f0a-7c4f-4d8f-8c5b-8f5b9d1c8c01", "A100-5C")
+
+			err := m.SetVGPUConfig(0, tc.config)
+			if tc.expectedErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+
+			if tc.cleared {
+				requireFileContent(t, "1", remove)
+			} else {
+				requireFileContent(t, "", remove)
+			}
+
+			for mdevType, dir := range mockMDEVTypeDirs {
+				create, err := os.ReadFile(filepath.Join(parents[0].Path, "mdev_supported_types", dir, "create"))
+				require.NoError(t, err)
+				if slices.Contains(tc.created, mdevType) {
+					require.NoError(t, uuid.Validate(string(create)))
+				} else {
+					require.Empty(t, create)
+				}
+
+				// Nothing is created on the other GPU
+				create, err = os.ReadFile(filepath.Join(parents[1].Path, "mdev_supported_types", dir, "create"))
+				require.NoError(t, err)
+				require.Empty(t, create)
+			}
+		})
+	}
+}
+
+func requireFileContent(t *testing.T, expected string, path string) {
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, expected, string(content))
+}
@@ -0,0 +1,174 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package vgpu
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/NVIDIA/go-nvlib/pkg/nvmdev"
+
+	"github.com/NVIDIA/vgpu-device-manager/pkg/types"
+)
+
+// maxInstanceRegex matches the 'max_instance' attribute in the description of an NVIDIA mdev type,
+// e.g. "num_heads=4, frl_config=60, framebuffer=1024M, max_resolution=5120x2880, max_instance=16".
+var maxInstanceRegex = regexp.MustCompile(`max_instance=([0-9]+)`)
+
+// getMaxMDEVInstances returns the maximum number of mdev devices of a particular type that can be created
+// across a set of 'parent' devices backed by the same GPU. It returns -1 if the maximum cannot be determined.
+func getMaxMDEVInstances(parents []*nvmdev.ParentDevice, mdevType string) (int, error) {
+	var supported []*nvmdev.ParentDevice
+	for _, p := range parents {
+		if p.IsMDEVTypeSupported(mdevType) {
+			supported = append(supported, p)
+		}
+	}
+
+	if len(supported) == 0 {
+		return 0, nil
+	}
+
+	maxInstances, err := readMaxMDEVInstances(supported[0], mdevType)
+	if err != nil {
+		return -1, err
+	}
+	if maxInstances < 0 {
+		return -1, nil
+	}
+
+	// With SR-IOV, each virtual function is a separate 'parent' device that
+	// can host at most one mdev device.
+	if len(supported) > 1 && len(supported) < maxInstances {
+		return len(supported), nil
+	}
+
+	return maxInstances, nil
+}
+
+// getAvailableMDEVInstances returns the number of mdev devices of a particular type that can still be created
+// across a set of 'parent' devices backed by the same GPU. With SR-IOV, this capacity is split across many parents.
+func getAvailableMDEVInstances(parents []*nvmdev.ParentDevice, mdevType string) (int, error) {
+	total := 0
+	for _, parent := range parents {
+		if !parent.IsMDEVTypeSupported(mdevType) {
+			continue
+		}
+		available, err := parent.GetAvailableMDEVInstances(mdevType)
+		if err != nil {
+			return -1, fmt.Errorf("error getting available vGPU instances: %v", err)
+		}
+		if available > 0 {
+			total += available
+		}
+	}
+	return total, nil
+}
+
+// getTotalMDEVInstances returns the total number of mdev devices of a particular type that a set of 'parent'
+// devices backed by the same GPU can hold, i.e. the instances still available plus the devices of that type
+// that already exist on them. Unlike getMaxMDEVInstances it does not depend on the 'max_instance' attribute,
+// but it only matches the maximum if no devices of other types currently exist on the GPU.
+func getTotalMDEVInstances(parents []*nvmdev.ParentDevice, devices []*nvmdev.Device, mdevType string) (int, error) {
+	total := 0
+	for _, p := range parents {
+		if !p.IsMDEVTypeSupported(mdevType) {
+			continue
+		}
+
+		available, err := p.GetAvailableMDEVInstances(mdevType)
+		if err != nil {
+			return -1, err
+		}
+		if available > 0 {
+			total += available
+		}
+
+		for _, d := range devices {
+			if d.MDEVType == mdevType && d.Parent != nil && d.Parent.Address == p.Address {
+				total++
+			}
+		}
+	}
+
+	return total, nil
+}
+
+// readMaxMDEVInstances reads the 'max_instance' attribute of an mdev type from the sysfs entry of a 'parent' device.
+// It returns -1 if the attribute is not present.
+func readMaxMDEVInstances(parent *nvmdev.ParentDevice, mdevType string) (int, error) {
+	paths, err := filepath.Glob(filepath.Join(parent.Path, "mdev_supported_types", "nvidia-*", "name"))
+	if err != nil {
+		return -1, fmt.Errorf("unable to get files in mdev_supported_types directory: %v", err)
+	}
+
+	for _, path := range paths {
+		name, err := readMDEVTypeName(path)
+		if err != nil {
+			return -1, err
+		}
+		if name != mdevType {
+			continue
+		}
+
+		description, err := os.ReadFile(filepath.Join(filepath.Dir(path), "description"))
+		if err != nil {
+			return -1, fmt.Errorf("unable to read description file: %v", err)
+		}
+		match := maxInstanceRegex.FindStringSubmatch(string(description))
+		if match == nil {
+			return -1, nil
+		}
+		return strconv.Atoi(match[1])
+	}
+
+	return -1, nil
+}
+
+// getSupportedMDEVTypes returns the names of all mdev types supported by a 'parent' device, in sorted order.
+func getSupportedMDEVTypes(parent *nvmdev.ParentDevice) ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(parent.Path, "mdev_supported_types", "nvidia-*", "name"))
+	if err != nil {
+		return nil, fmt.Errorf("unable to get files in mdev_supported_types directory: %v", err)
+	}
+
+	var mdevTypes []string
+	for _, path := range paths {
+		name, err := readMDEVTypeName(path)
+		if err != nil {
+			return nil, err
+		}
+		mdevTypes = append(mdevTypes, name)
+	}
+	sort.Strings(mdevTypes)
+
+	return mdevTypes, nil
+}
+
+// readMDEVTypeName reads the vGPU type from the 'name' file of an mdev type, in the form go-nvlib's nvmdev uses.
+func readMDEVTypeName(path string) (string, error) {
+	name, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("unable to read file %s: %v", path, err)
+	}
+	// file in the format: [NVIDIA|GRID] <vGPU type>
+	return types.MDEVTypeName(string(name)), nil
+}
@@ -0,0 +1,57 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package vgpu
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestProductNamedMDEVTypes(t *testing.T) {
+	const productType = "RTX Pro 6000 Blackwell DC-48C"
+
+	mock := newMockNvmdev(t, []string{"0000:3b:00.0"}, map[string]int{"A100-4C": 2}, 4)
+	parents, err := mock.GetAllParentDevices()
+	require.NoError(t, err)
+	require.Len(t, parents, 1)
+
+	// Rename the 'A100-4C' type, the way a product-named type appears in sysfs.
+	nameFile := filepath.Join(parents[0].Path, "mdev_supported_types", mockMDEVTypeDirs["A100-4C"], "name")
+	require.NoError(t, os.WriteFile(nameFile, []byte("NVIDIA "+productType+"\n"), 0644))
+	parents, err = mock.GetAllParentDevices()
+	require.NoError(t, err)
+	parent := parents[0]
+
+	mdevTypes, err := getSupportedMDEVTypes(parent)
+	require.NoError(t, err)
+	require.Contains(t, mdevTypes, productType)
+	require.NotContains(t, mdevTypes, "A100-4C")
+	for _, mdevType := range mdevTypes {
+		require.True(t, parent.IsMDEVTypeSupported(mdevType), mdevType)
+	}
+
+	maxInstances, err := readMaxMDEVInstances(parent, productType)
+	require.NoError(t, err)
+	require.Equal(t, 4, maxInstances)
+
+	available, err := getAvailableMDEVInstances(parents, productType)
+	require.NoError(t, err)
+	require.Equal(t, 2, available)
+}
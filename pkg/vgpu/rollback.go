@@ -0,0 +1,105 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package vgpu
+
+import (
+	"errors"
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/NVIDIA/vgpu-device-manager/pkg/types"
+)
+
+// Snapshot records the vGPU config of a set of GPUs before they are changed, so that it can be restored
+type Snapshot struct {
+	gpus    []int
+	configs map[int]types.VGPUConfig
+}
+
+// RollbackError is returned when restoring a 'Snapshot' after a failed change fails itself.
+// It wraps both the error that caused the rollback and the joined errors restoring 'FailedGPUs'.
+type RollbackError struct {
+	Cause      error
+	FailedGPUs []int
+	Err        error
+	RolledBack []int
+}
+
+var _ error = (*RollbackError)(nil)
+
+// NewSnapshot creates an empty 'Snapshot'
+func NewSnapshot() *Snapshot {
+	return &Snapshot{
+		configs: make(map[int]types.VGPUConfig),
+	}
+}
+
+// Add records 'config' as the config of the GPU at index 'gpu'.
+// Only the first config recorded for a GPU is kept, since that is the one it had before any change.
+func (s *Snapshot) Add(gpu int, config types.VGPUConfig) {
+	if _, exists := s.configs[gpu]; exists {
+		return
+	}
+	s.gpus = append(s.gpus, gpu)
+	s.configs[gpu] = config
+}
+
+// Restore sets every GPU in the snapshot back to its recorded config after a change failed with 'cause',
+// in the reverse of the order the GPUs were recorded in. GPUs already at their recorded config are left
+// untouched. A GPU that cannot be rolled back does not stop the others from being restored; once all GPUs
+// have been attempted, a '*RollbackError' joining the errors of every failed GPU is returned. It returns the
+// GPUs that were rolled back.
+func (s *Snapshot) Restore(m Manager, cause error) ([]int, error) {
+	var rolledBack, failed []int
+	var errs []error
+	for i := len(s.gpus) - 1; i >= 0; i-- {
+		gpu := s.gpus[i]
+		config := s.configs[gpu]
+
+		current, err := m.GetVGPUConfig(gpu)
+		if err == nil && current.Equals(config) {
+			log.WithField("gpu_index", gpu).Debugf("Skipping rollback -- already set to previous vGPU config")
+			continue
+		}
+
+		log.WithField("gpu_index", gpu).Warnf("Rolling back to vGPU config: %v", config)
+		err = m.SetVGPUConfig(gpu, config)
+		if err != nil {
+			log.WithField("gpu_index", gpu).Errorf("Rollback failed: %v", err)
+			failed = append(failed, gpu)
+			errs = append(errs, fmt.Errorf("GPU %d: %w", gpu, err))
+			continue
+		}
+		rolledBack = append(rolledBack, gpu)
+	}
+
+	if len(errs) > 0 {
+		return rolledBack, &RollbackError{Cause: cause, FailedGPUs: failed, Err: errors.Join(errs...), RolledBack: rolledBack}
+	}
+	return rolledBack, nil
+}
+
+// Error returns a 'RollbackError' as a string, making clear that the node needs manual intervention
+func (e *RollbackError) Error() string {
+	return fmt.Sprintf("%v; rolled back GPUs %v; rollback of GPUs %v also failed, manual intervention required: %v", e.Cause, e.RolledBack, e.FailedGPUs, e.Err)
+}
+
+// Unwrap returns both the error that caused the rollback and the joined errors restoring the GPUs
+func (e *RollbackError) Unwrap() []error {
+	return []error{e.Cause, e.Err}
+}
@@ -0,0 +1,152 @@
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION.  All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package vgpu
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/NVIDIA/vgpu-device-manager/pkg/types"
+)
+
+// fakeManager is a 'Manager' holding the vGPU config of each GPU in memory.
+// Setting the config of a GPU in 'setErrors' fails with that error.
+type fakeManager struct {
+	Manager
+	configs   map[int]types.VGPUConfig
+	setErrors map[int]error
+	setCalls  []int
+}
+
+func (m *fakeManager) GetVGPUConfig(gpu int) (types.VGPUConfig, error) {
+	return m.configs[gpu], nil
+}
+
+func (m *fakeManager) SetVGPUConfig(gpu int, config types.VGPUConfig) error {
+	m.setCalls = append(m.setCalls, gpu)
+	if err := m.setErrors[gpu]; err != nil {
+		return err
+	}
+	m.configs[gpu] = config
+	return nil
+}
+
+func TestSnapshotRestore(t *testing.T) {
+	previous := types.VGPUConfig{"A100-4C": 10}
+	applied := types.VGPUConfig{"A100-5C": 8}
+	cause := errors.New("error setting VGPU config")
+	restoreErr := errors.New("device busy")
+
+	testCases := []struct {
+		description        string
+		recorded           []int
+		current            map[int]types.VGPUConfig
+		setErrors          map[int]error
+		expectedSetCalls   []int
+		expectedRolledBack []int
+		expectedFailedGPUs []int
+	}{
+		{
+			"GPUs are restored in reverse order",
+			[]int{0, 1, 2},
+			map[int]types.VGPUConfig{0: applied, 1: applied, 2: applied},
+			nil,
+			[]int{2, 1, 0},
+			[]int{2, 1, 0},
+			nil,
+		},
+		{
+			"GPUs already at their previous config are skipped",
+			[]int{0, 1, 2},
+			map[int]types.VGPUConfig{0: applied, 1: previous, 2: applied},
+			nil,
+			[]int{2, 0},
+			[]int{2, 0},
+			nil,
+		},
+		{
+			"Restoring continues after a failure",
+			[]int{0, 1, 2},
+			map[int]types.VGPUConfig{0: applied, 1: applied, 2: applied},
+			map[int]error{1: restoreErr},
+			[]int{2, 1, 0},
+			[]int{2, 0},
+			[]int{1},
+		},
+		{
+			"Every failure is reported",
+			[]int{0, 1, 2},
+			map[int]types.VGPUConfig{0: applied, 1: applied, 2: applied},
+			map[int]error{2: restoreErr, 0: restoreErr},
+			[]int{2, 1, 0},
+			[]int{1},
+			[]int{2, 0},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			snapshot := NewSnapshot()
+			for _, gpu := range tc.recorded {
+				snapshot.Add(gpu, previous)
+				// Only the first config recorded for a GPU is kept
+				snapshot.Add(gpu, applied)
+			}
+
+			m := &fakeManager{configs: tc.current, setErrors: tc.setErrors}
+			rolledBack, err := snapshot.Restore(m, cause)
+			require.Equal(t, tc.expectedSetCalls, m.setCalls)
+			require.Equal(t, tc.expectedRolledBack, rolledBack)
+
+			if tc.expectedFailedGPUs == nil {
+				require.NoError(t, err)
+				for _, gpu := range tc.recorded {
+					require.Equal(t, previous, m.configs[gpu])
+				}
+				return
+			}
+
+			var rollbackErr *RollbackError
+			require.ErrorAs(t, err, &rollbackErr)
+			require.Equal(t, tc.expectedFailedGPUs, rollbackErr.FailedGPUs)
+			require.Equal(t, tc.expectedRolledBack, rollbackErr.RolledBack)
+			require.ErrorIs(t, err, cause)
+			require.ErrorIs(t, err, restoreErr)
+		})
+	}
+}
+
+func TestRollbackError(t *testing.T) {
+	cause := errors.New("error setting VGPU config")
+	restoreErr := errors.New("device busy")
+	removeErr := errors.New("unable to remove device")
+
+	err := fmt.Errorf("apply failed: %w", &RollbackError{
+		Cause:      cause,
+		FailedGPUs: []int{3, 1},
+		Err:        errors.Join(fmt.Errorf("GPU 3: %w", restoreErr), fmt.Errorf("GPU 1: %w", removeErr)),
+		RolledBack: []int{2},
+	})
+
+	require.ErrorIs(t, err, cause)
+	require.ErrorIs(t, err, restoreErr)
+	require.ErrorIs(t, err, removeErr)
+	require.Equal(t, "apply failed: error setting VGPU config; rolled back GPUs [2]; rollback of GPUs [3 1] also failed, manual intervention required: GPU 3: device busy\nGPU 1: unable to remove device", err.Error())
+}
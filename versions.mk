@@ -19,3 +19,4 @@ vVERSION := v$(VERSION:v%=%)
 GOLANG_VERSION ?= 1.22.8
 
 GIT_COMMIT ?= $(shell git describe --match="" --dirty --long --always --abbrev=40 2> /dev/null || echo "")
+BUILD_DATE ?= $(shell date -u +%Y-%m-%dT%H:%M:%SZ)